@@ -1,25 +1,104 @@
 package buttons
 
+import (
+    "fmt"
+    "sync/atomic"
+)
+
 type Pin interface {
     Get() bool
 }
 
+// PinBank reads multiple inputs in one operation, where bit n of Get() is the level of input n
+type PinBank interface {
+    Get() uint32
+}
+
 type Button struct {
-    name     string
-    pin      Pin
-    config   *ButtonConfig
-    history  historyType
-    filtered historyType
-    rptCnt   uint8
+    name         string
+    pin          Pin
+    bankBit      int8 // bit of PinBank snapshot to read instead of pin (-1 if pin is used)
+    config       *ButtonConfig
+    history      historyType
+    filtered     historyType
+    rptCnt       uint8
+    rptWait      uint8  // scans to wait for next Repeat
+    rptScans     uint16 // scans since Repeat started
+    pressed      bool
+    lastPressed  bool
+    muted        bool // events are suppressed until released (e.g. member of chord in progress)
+    clickHeld    bool // Click-then-Hold detected in current push
+    longFired    bool // Long/LongLong/Click-then-Hold sent in current push
+    holdCnt      uint16 // scans held in current (or last) push
+    coalesced    ButtonEvent // latest Repeat event waiting for room of event queue (OVERFLOW_COALESCE)
+    hasCoalesced bool
+    pending      atomic.Pointer[buttonUpdate] // update waiting to be applied at the next scan
+    disabled     atomic.Bool // events are masked while scan keeps going
+    wasDisabled  bool
+    holdBack     bool // events are held back while a chord including the button may still complete
+    held         [chordHeldMax]ButtonEvent
+    heldCnt      uint8
+    heldFull     bool // held back events overflowed, events are sent without holding back until released
 }
 
 func NewButton(name string, pin Pin, config *ButtonConfig) *Button {
     button := Button {
         name: name,
         pin: pin,
+        bankBit: -1,
         config: config,
-        history: newHistory(false),
-        filtered: newHistory(false),
+        history: newHistory(config.historyWords(), false),
+        filtered: newHistory(config.historyWords(), false),
     }
     return &button
 }
+
+const pinBankBits = 32
+
+// NewBankButton makes a button read from bit (0 to 31) of the PinBank given to Buttons.SetPinBank()
+func NewBankButton(name string, bit uint8, config *ButtonConfig) (*Button, error) {
+    if bit >= pinBankBits {
+        return nil, fmt.Errorf("button %s: bank bit %d is out of range (0 to %d)", name, bit, pinBankBits - 1)
+    }
+    button := NewButton(name, nil, config)
+    button.bankBit = int8(bit)
+    return button, nil
+}
+
+// buttonUpdate is prepared out of ScanPeriodic (allocation included) and applied by ScanPeriodic at a scan boundary
+type buttonUpdate struct {
+    config   *ButtonConfig
+    history  historyType
+    filtered historyType
+}
+
+func newButtonUpdate(config *ButtonConfig) *buttonUpdate {
+    return &buttonUpdate {
+        config: config,
+        history: newHistory(config.historyWords(), false),
+        // initialize as all pushed not to detect clicks from the past
+        filtered: newHistory(config.historyWords(), true),
+    }
+}
+
+// latestConfig returns the config to be applied if pending, otherwise the current config
+func (button *Button) latestConfig() *ButtonConfig {
+    if update := button.pending.Load(); update != nil {
+        return update.config
+    }
+    return button.config
+}
+
+// applyUpdate replaces config and resets history and detection status of button (called by ScanPeriodic)
+func (button *Button) applyUpdate(update *buttonUpdate) {
+    button.config = update.config
+    button.history = update.history
+    button.filtered = update.filtered
+    button.rptCnt = 0
+    button.rptWait = 0
+    button.rptScans = 0
+    button.clickHeld = false
+    button.longFired = false
+    // keep silent until released if pushed across the update
+    button.muted = button.muted || button.pressed
+}
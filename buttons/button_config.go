@@ -1,16 +1,21 @@
 package buttons
 
-const historySize = 64 // history size is fixed to 64 thanks to uint64 math/bits calculation
+const holdTierMaxNum = 32
 
 type ButtonConfig struct {
     activeHigh bool         // Set false if button is connected between GND and pin with pull-up
     multiClicks bool        // Detect multiple clicks if true, detect single click if false
     filterSize uint8        // filter size to process raw status
     actFinishCnt uint8      // Button action detection starts when status keeps false at latest continuous actFinishCnt times (only if multiClicks)
-    repeatDetectCnt uint8   // continuous counts to detect Repeat click when continuous push (only if !multiClicks. ignored if 0. if defined Long/LongLong detect disabled)
+    repeatDetectCnt uint8   // continuous counts to detect Repeat click when continuous push (only if !multiClicks. ignored if 0. Long/LongLong are sent before Repeat at the same scan)
     repeatSkip uint8        // skip count for Repeat click detection (every scan if 0)
+    repeatProfile []RepeatStage // stages to change repeatSkip while continuous push (sorted by AfterCnt)
     longDetectCnt uint8     // continuous counts to detect Long Push (ignored if 0)
     longLongDetectCnt uint8 // continuous counts to detect LongLong Push (ignored if 0)
+    holdTiers []uint8       // continuous counts to detect each hold tier, overrides Long/LongLong (tier 0 and 1) if not empty
+    pressRelease bool       // Detect Press/Release edges of filtered status if true
+    clickHold bool          // Detect Click-then-Hold instead of Long if clicked before the push reaches longDetectCnt (only if multiClicks)
+    timing *ButtonTiming    // durations to derive counts from when scan period changes (nil if given by counts)
 }
 
 var DefaultButtonSingleConfig = &ButtonConfig {
@@ -22,6 +27,7 @@ var DefaultButtonSingleConfig = &ButtonConfig {
     repeatSkip: 0,
     longDetectCnt: 0,
     longLongDetectCnt: 0,
+    pressRelease: false,
 }
 
 var DefaultButtonSingleRepeatConfig = &ButtonConfig {
@@ -33,6 +39,7 @@ var DefaultButtonSingleRepeatConfig = &ButtonConfig {
     repeatSkip: 2,
     longDetectCnt: 0,
     longLongDetectCnt: 0,
+    pressRelease: false,
 }
 
 var DefaultButtonMultiConfig = &ButtonConfig {
@@ -44,6 +51,7 @@ var DefaultButtonMultiConfig = &ButtonConfig {
     repeatSkip: 2,
     longDetectCnt: 15,
     longLongDetectCnt: 39,
+    pressRelease: false,
 }
 
 func NewButtonConfig(
@@ -59,11 +67,87 @@ func NewButtonConfig(
         repeatSkip: repeatSkip,
         longDetectCnt: longDetectCnt,
         longLongDetectCnt: longLongDetectCnt,
+        pressRelease: false,
     }
     config.reflectConstraints()
     return config
 }
 
+// RepeatStage changes skip count of Repeat click detection after continuous push of AfterCnt scans since Repeat started
+type RepeatStage struct {
+    AfterCnt uint8
+    Skip     uint8
+}
+
+// WithRepeatProfile returns a copy of config with accelerating Repeat click detection,
+// where repeatSkip is used until the first stage and each stage's Skip is used after its AfterCnt.
+// The copy of a config made by NewButtonConfigByTiming keeps its current counts and is no longer re-derived
+// by scan period (give ButtonTiming.RepeatProfile instead to keep the stages in durations)
+func (config *ButtonConfig) WithRepeatProfile(stage ...RepeatStage) *ButtonConfig {
+    newConfig := *config
+    newConfig.repeatProfile = append([]RepeatStage{}, stage...)
+    newConfig.timing = nil
+    newConfig.reflectConstraints()
+    return &newConfig
+}
+
+// repeatSkipAt returns skip count of Repeat click detection after rptScans since Repeat started
+func (config *ButtonConfig) repeatSkipAt(rptScans uint16) uint8 {
+    skip := config.repeatSkip
+    for _, stage := range config.repeatProfile {
+        if rptScans < uint16(stage.AfterCnt) {
+            break
+        }
+        skip = stage.Skip
+    }
+    return skip
+}
+
+// WithHoldTiers returns a copy of config detecting hold tiers at each continuous count (ignored if 0).
+// Tier 0 and 1 are reported as EVT_LONG and EVT_LONG_LONG, and the others as EVT_HOLD with Tier.
+// The copy of a config made by NewButtonConfigByTiming keeps its current counts and is no longer re-derived
+// by scan period (give ButtonTiming.HoldTiers instead to keep them in durations)
+func (config *ButtonConfig) WithHoldTiers(cnt ...uint8) *ButtonConfig {
+    newConfig := *config
+    newConfig.holdTiers = append([]uint8{}, cnt...)
+    newConfig.timing = nil
+    newConfig.reflectConstraints()
+    return &newConfig
+}
+
+func (config *ButtonConfig) holdTierNum() int {
+    if len(config.holdTiers) > 0 {
+        return len(config.holdTiers)
+    }
+    return 2
+}
+
+func (config *ButtonConfig) holdTierCnt(tier int) uint8 {
+    if len(config.holdTiers) > 0 {
+        return config.holdTiers[tier]
+    }
+    if tier == 0 {
+        return config.longDetectCnt
+    }
+    return config.longLongDetectCnt
+}
+
+// WithClickHold returns a copy of config with Click-then-Hold detection enabled or disabled
+func (config *ButtonConfig) WithClickHold(clickHold bool) *ButtonConfig {
+    newConfig := *config
+    newConfig.clickHold = clickHold
+    newConfig.reflectConstraints()
+    return &newConfig
+}
+
+// WithPressRelease returns a copy of config with Press/Release edge detection enabled or disabled
+func (config *ButtonConfig) WithPressRelease(pressRelease bool) *ButtonConfig {
+    newConfig := *config
+    newConfig.pressRelease = pressRelease
+    newConfig.reflectConstraints()
+    return &newConfig
+}
+
 func (config *ButtonConfig) reflectConstraints() {
     // revise illegal settings
     if config.filterSize < 1 {
@@ -71,13 +155,51 @@ func (config *ButtonConfig) reflectConstraints() {
     }
     if !config.multiClicks {
         config.actFinishCnt = 0
-    } else if config.actFinishCnt > historySize {
-        config.actFinishCnt = historySize
+        config.clickHold = false
+    }
+    // sort stages of repeatProfile by AfterCnt
+    for i := 1; i < len(config.repeatProfile); i++ {
+        for j := i; j > 0 && config.repeatProfile[j - 1].AfterCnt > config.repeatProfile[j].AfterCnt; j-- {
+            config.repeatProfile[j - 1], config.repeatProfile[j] = config.repeatProfile[j], config.repeatProfile[j - 1]
+        }
     }
-    if config.longDetectCnt > historySize - 1 {
-        config.longDetectCnt = historySize - 1
+    if len(config.holdTiers) > holdTierMaxNum {
+        config.holdTiers = config.holdTiers[:holdTierMaxNum]
+    }
+    for i := range config.holdTiers {
+        if config.holdTiers[i] > historyMaxCnt - 1 {
+            config.holdTiers[i] = historyMaxCnt - 1
+        }
+    }
+    // Long/LongLong reflect tier 0 and 1
+    if len(config.holdTiers) > 0 {
+        config.longDetectCnt = config.holdTiers[0]
+        config.longLongDetectCnt = 0
+        if len(config.holdTiers) > 1 {
+            config.longLongDetectCnt = config.holdTiers[1]
+        }
+    }
+    if config.longDetectCnt > historyMaxCnt - 1 {
+        config.longDetectCnt = historyMaxCnt - 1
+    }
+    if config.longLongDetectCnt > historyMaxCnt - 1 {
+        config.longLongDetectCnt = historyMaxCnt - 1
+    }
+}
+
+// historyWords returns the number of history words to cover the largest count of config
+func (config *ButtonConfig) historyWords() int {
+    size := int(config.filterSize)
+    for _, cnt := range []uint8{config.actFinishCnt, config.repeatDetectCnt} {
+        if int(cnt) > size {
+            size = int(cnt)
+        }
     }
-    if config.longLongDetectCnt > historySize - 1 {
-        config.longLongDetectCnt = historySize - 1
+    // hold tiers need one more sample not to detect again while saturated
+    for tier := 0; tier < config.holdTierNum(); tier++ {
+        if cnt := config.holdTierCnt(tier); int(cnt) + 1 > size {
+            size = int(cnt) + 1
+        }
     }
+    return (size + historyWordSize - 1) / historyWordSize
 }
@@ -0,0 +1,241 @@
+package buttons
+
+import (
+    "fmt"
+    "strings"
+)
+
+// ConfigError lists every invalid or conflicting setting of ButtonConfig
+type ConfigError struct {
+    Problems []string
+}
+
+func (err *ConfigError) Error() string {
+    return "invalid ButtonConfig: " + strings.Join(err.Problems, "; ")
+}
+
+// ButtonConfigBuilder builds ButtonConfig with validation instead of silent revision by NewButtonConfig()
+type ButtonConfigBuilder struct {
+    config ButtonConfig
+}
+
+// NewButtonConfigBuilder starts from a copy of base (e.g. DefaultButtonMultiConfig), or from filterSize 1 and the others zero if base is nil
+func NewButtonConfigBuilder(base *ButtonConfig) *ButtonConfigBuilder {
+    builder := &ButtonConfigBuilder{}
+    if base != nil {
+        builder.config = *base
+        builder.config.repeatProfile = append([]RepeatStage{}, base.repeatProfile...)
+        builder.config.holdTiers = append([]uint8{}, base.holdTiers...)
+    } else {
+        builder.config.filterSize = 1
+    }
+    // counts given to builder are not re-derived from durations
+    builder.config.timing = nil
+    return builder
+}
+
+func (builder *ButtonConfigBuilder) ActiveHigh(activeHigh bool) *ButtonConfigBuilder {
+    builder.config.activeHigh = activeHigh
+    return builder
+}
+
+func (builder *ButtonConfigBuilder) MultiClicks(multiClicks bool) *ButtonConfigBuilder {
+    builder.config.multiClicks = multiClicks
+    return builder
+}
+
+func (builder *ButtonConfigBuilder) FilterSize(filterSize uint8) *ButtonConfigBuilder {
+    builder.config.filterSize = filterSize
+    return builder
+}
+
+func (builder *ButtonConfigBuilder) ActFinishCnt(actFinishCnt uint8) *ButtonConfigBuilder {
+    builder.config.actFinishCnt = actFinishCnt
+    return builder
+}
+
+func (builder *ButtonConfigBuilder) RepeatDetectCnt(repeatDetectCnt uint8) *ButtonConfigBuilder {
+    builder.config.repeatDetectCnt = repeatDetectCnt
+    return builder
+}
+
+func (builder *ButtonConfigBuilder) RepeatSkip(repeatSkip uint8) *ButtonConfigBuilder {
+    builder.config.repeatSkip = repeatSkip
+    return builder
+}
+
+func (builder *ButtonConfigBuilder) RepeatProfile(stage ...RepeatStage) *ButtonConfigBuilder {
+    builder.config.repeatProfile = append([]RepeatStage{}, stage...)
+    return builder
+}
+
+// LongDetectCnt sets Long, which clears hold tiers
+func (builder *ButtonConfigBuilder) LongDetectCnt(longDetectCnt uint8) *ButtonConfigBuilder {
+    builder.config.longDetectCnt = longDetectCnt
+    builder.config.holdTiers = nil
+    return builder
+}
+
+// LongLongDetectCnt sets LongLong, which clears hold tiers
+func (builder *ButtonConfigBuilder) LongLongDetectCnt(longLongDetectCnt uint8) *ButtonConfigBuilder {
+    builder.config.longLongDetectCnt = longLongDetectCnt
+    builder.config.holdTiers = nil
+    return builder
+}
+
+// HoldTiers sets hold tiers, which overrides Long and LongLong
+func (builder *ButtonConfigBuilder) HoldTiers(cnt ...uint8) *ButtonConfigBuilder {
+    builder.config.holdTiers = append([]uint8{}, cnt...)
+    builder.config.longDetectCnt = 0
+    builder.config.longLongDetectCnt = 0
+    if len(cnt) > 0 {
+        builder.config.longDetectCnt = cnt[0]
+    }
+    if len(cnt) > 1 {
+        builder.config.longLongDetectCnt = cnt[1]
+    }
+    return builder
+}
+
+func (builder *ButtonConfigBuilder) PressRelease(pressRelease bool) *ButtonConfigBuilder {
+    builder.config.pressRelease = pressRelease
+    return builder
+}
+
+func (builder *ButtonConfigBuilder) ClickHold(clickHold bool) *ButtonConfigBuilder {
+    builder.config.clickHold = clickHold
+    return builder
+}
+
+// Build returns ButtonConfig, or ConfigError listing every invalid or conflicting setting
+func (builder *ButtonConfigBuilder) Build() (*ButtonConfig, error) {
+    if err := builder.config.Validate(); err != nil {
+        return nil, err
+    }
+    config := builder.config
+    config.repeatProfile = append([]RepeatStage{}, builder.config.repeatProfile...)
+    config.holdTiers = append([]uint8{}, builder.config.holdTiers...)
+    config.reflectConstraints()
+    return &config, nil
+}
+
+// Validate returns ConfigError listing every setting that reflectConstraints() would revise or that conflicts with others
+func (config *ButtonConfig) Validate() error {
+    problems := []string{}
+    if config.filterSize < 1 {
+        problems = append(problems, "filterSize must be 1 or more")
+    }
+    if config.multiClicks {
+        if config.actFinishCnt == 0 {
+            problems = append(problems, "actFinishCnt must be 1 or more with multiClicks")
+        }
+        if config.repeatDetectCnt > 0 {
+            problems = append(problems, "repeatDetectCnt conflicts with multiClicks")
+        }
+    } else {
+        if config.actFinishCnt > 0 {
+            problems = append(problems, "actFinishCnt is only for multiClicks")
+        }
+        if config.clickHold {
+            problems = append(problems, "clickHold is only for multiClicks")
+        }
+    }
+    if config.clickHold && config.longDetectCnt == 0 {
+        problems = append(problems, "clickHold needs longDetectCnt")
+    }
+    if config.repeatDetectCnt == 0 && len(config.repeatProfile) > 0 {
+        problems = append(problems, "repeatProfile needs repeatDetectCnt")
+    }
+    for i := 1; i < len(config.repeatProfile); i++ {
+        if config.repeatProfile[i - 1].AfterCnt >= config.repeatProfile[i].AfterCnt {
+            problems = append(problems, "AfterCnt of repeatProfile must be ascending")
+            break
+        }
+    }
+    if config.repeatDetectCnt > 0 && config.repeatDetectCnt < config.longDetectCnt {
+        problems = append(problems, fmt.Sprintf("Repeat (repeatDetectCnt %d) starts before Long (longDetectCnt %d)", config.repeatDetectCnt, config.longDetectCnt))
+    }
+    if len(config.holdTiers) > 0 {
+        if len(config.holdTiers) > holdTierMaxNum {
+            problems = append(problems, fmt.Sprintf("hold tiers must be %d or less", holdTierMaxNum))
+        }
+        longLongDetectCnt := uint8(0)
+        if len(config.holdTiers) > 1 {
+            longLongDetectCnt = config.holdTiers[1]
+        }
+        if config.longDetectCnt != config.holdTiers[0] || config.longLongDetectCnt != longLongDetectCnt {
+            problems = append(problems, "longDetectCnt/longLongDetectCnt conflict with hold tiers")
+        }
+    } else if config.longDetectCnt > 0 && config.longLongDetectCnt > 0 && config.longLongDetectCnt <= config.longDetectCnt {
+        problems = append(problems, "longLongDetectCnt must be larger than longDetectCnt")
+    }
+    for tier := 0; tier < config.holdTierNum(); tier++ {
+        cnt := config.holdTierCnt(tier)
+        if cnt > historyMaxCnt - 1 {
+            problems = append(problems, fmt.Sprintf("hold tier %d (%d) must be %d or less", tier, cnt, historyMaxCnt - 1))
+        }
+    }
+    for i := 1; i < len(config.holdTiers); i++ {
+        if config.holdTiers[i - 1] >= config.holdTiers[i] {
+            problems = append(problems, "hold tiers must be ascending")
+            break
+        }
+    }
+    if len(problems) > 0 {
+        return &ConfigError{Problems: problems}
+    }
+    return nil
+}
+
+func (config *ButtonConfig) ActiveHigh() bool {
+    return config.activeHigh
+}
+
+func (config *ButtonConfig) MultiClicks() bool {
+    return config.multiClicks
+}
+
+func (config *ButtonConfig) FilterSize() uint8 {
+    return config.filterSize
+}
+
+func (config *ButtonConfig) ActFinishCnt() uint8 {
+    return config.actFinishCnt
+}
+
+func (config *ButtonConfig) RepeatDetectCnt() uint8 {
+    return config.repeatDetectCnt
+}
+
+func (config *ButtonConfig) RepeatSkip() uint8 {
+    return config.repeatSkip
+}
+
+func (config *ButtonConfig) RepeatProfile() []RepeatStage {
+    return append([]RepeatStage{}, config.repeatProfile...)
+}
+
+func (config *ButtonConfig) LongDetectCnt() uint8 {
+    return config.longDetectCnt
+}
+
+func (config *ButtonConfig) LongLongDetectCnt() uint8 {
+    return config.longLongDetectCnt
+}
+
+// HoldTiers returns counts of all hold tiers including Long and LongLong
+func (config *ButtonConfig) HoldTiers() []uint8 {
+    cnts := []uint8{}
+    for tier := 0; tier < config.holdTierNum(); tier++ {
+        cnts = append(cnts, config.holdTierCnt(tier))
+    }
+    return cnts
+}
+
+func (config *ButtonConfig) PressRelease() bool {
+    return config.pressRelease
+}
+
+func (config *ButtonConfig) ClickHold() bool {
+    return config.clickHold
+}
@@ -0,0 +1,97 @@
+package buttons
+
+import (
+    "errors"
+    "testing"
+)
+
+func ascendingCnts(n int) []uint8 {
+    cnts := make([]uint8, n)
+    for i := range cnts {
+        cnts[i] = uint8(i + 1)
+    }
+    return cnts
+}
+
+func TestBuilderProblems(t *testing.T) {
+    for _, tc := range []struct {
+        name    string
+        builder *ButtonConfigBuilder
+        want    []string
+    } {
+        {"filterSize", NewButtonConfigBuilder(nil).FilterSize(0),
+            []string{"filterSize must be 1 or more"}},
+        {"actFinishCnt with multiClicks", NewButtonConfigBuilder(DefaultButtonMultiConfig).ActFinishCnt(0),
+            []string{"actFinishCnt must be 1 or more with multiClicks"}},
+        {"repeat with multiClicks", NewButtonConfigBuilder(DefaultButtonMultiConfig).RepeatDetectCnt(50),
+            []string{"repeatDetectCnt conflicts with multiClicks"}},
+        {"actFinishCnt without multiClicks", NewButtonConfigBuilder(nil).ActFinishCnt(5),
+            []string{"actFinishCnt is only for multiClicks"}},
+        {"clickHold without multiClicks", NewButtonConfigBuilder(nil).LongDetectCnt(15).ClickHold(true),
+            []string{"clickHold is only for multiClicks"}},
+        {"clickHold without Long", NewButtonConfigBuilder(DefaultButtonMultiConfig).LongDetectCnt(0).LongLongDetectCnt(0).ClickHold(true),
+            []string{"clickHold needs longDetectCnt"}},
+        {"repeatProfile without Repeat", NewButtonConfigBuilder(nil).RepeatProfile(RepeatStage{AfterCnt: 20, Skip: 1}),
+            []string{"repeatProfile needs repeatDetectCnt"}},
+        {"repeatProfile order", NewButtonConfigBuilder(DefaultButtonSingleRepeatConfig).RepeatProfile(
+            RepeatStage{AfterCnt: 40, Skip: 0}, RepeatStage{AfterCnt: 20, Skip: 1}, RepeatStage{AfterCnt: 10, Skip: 1}),
+            []string{"AfterCnt of repeatProfile must be ascending"}},
+        {"Repeat before Long", NewButtonConfigBuilder(DefaultButtonSingleRepeatConfig).LongDetectCnt(15),
+            []string{"Repeat (repeatDetectCnt 10) starts before Long (longDetectCnt 15)"}},
+        {"too many hold tiers", NewButtonConfigBuilder(nil).HoldTiers(ascendingCnts(holdTierMaxNum + 1)...),
+            []string{"hold tiers must be 32 or less"}},
+        {"LongLong before Long", NewButtonConfigBuilder(nil).LongDetectCnt(20).LongLongDetectCnt(10),
+            []string{"longLongDetectCnt must be larger than longDetectCnt"}},
+        {"Long saturated", NewButtonConfigBuilder(nil).LongDetectCnt(255),
+            []string{"hold tier 0 (255) must be 254 or less"}},
+        {"hold tiers order", NewButtonConfigBuilder(nil).HoldTiers(30, 20, 10),
+            []string{"hold tiers must be ascending"}},
+        {"several problems", NewButtonConfigBuilder(nil).FilterSize(0).ActFinishCnt(5).HoldTiers(30, 20, 10),
+            []string{"filterSize must be 1 or more", "actFinishCnt is only for multiClicks", "hold tiers must be ascending"}},
+    } {
+        config, err := tc.builder.Build()
+        var configErr *ConfigError
+        if config != nil || !errors.As(err, &configErr) {
+            t.Fatalf("%s: config %v, error %v", tc.name, config, err)
+        }
+        if len(configErr.Problems) != len(tc.want) {
+            t.Fatalf("%s: problems %q, want %q", tc.name, configErr.Problems, tc.want)
+        }
+        for i := range tc.want {
+            if configErr.Problems[i] != tc.want[i] {
+                t.Fatalf("%s: problems %q, want %q", tc.name, configErr.Problems, tc.want)
+            }
+        }
+    }
+}
+
+func TestValidateHoldTiersConflict(t *testing.T) {
+    config := &ButtonConfig{filterSize: 1, longDetectCnt: 5, longLongDetectCnt: 20, holdTiers: []uint8{10, 20}}
+    var configErr *ConfigError
+    if err := config.Validate(); !errors.As(err, &configErr) || len(configErr.Problems) != 1 ||
+       configErr.Problems[0] != "longDetectCnt/longLongDetectCnt conflict with hold tiers" {
+        t.Fatalf("error %v", err)
+    }
+}
+
+func TestBuilderPresets(t *testing.T) {
+    for name, preset := range map[string]*ButtonConfig {
+        "single": DefaultButtonSingleConfig,
+        "singleRepeat": DefaultButtonSingleRepeatConfig,
+        "multi": DefaultButtonMultiConfig,
+    } {
+        if err := preset.Validate(); err != nil {
+            t.Fatalf("%s: %v", name, err)
+        }
+        config, err := NewButtonConfigBuilder(preset).Build()
+        if err != nil {
+            t.Fatalf("%s: %v", name, err)
+        }
+        if !equalConfig(config, preset) {
+            t.Fatalf("%s: built %v, want %v", name, config, preset)
+        }
+    }
+    if _, err := NewButtonConfigBuilder(nil).Build(); err != nil {
+        t.Fatalf("empty builder: %v", err)
+    }
+}
@@ -0,0 +1,282 @@
+package buttons
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+)
+
+// fields of ButtonConfig in order of serialization (repeatProfile and holdTiers follow if not empty)
+var configBoolFields = []struct {
+    key string
+    ptr func(config *ButtonConfig) *bool
+} {
+    {"activeHigh", func(config *ButtonConfig) *bool { return &config.activeHigh }},
+    {"multiClicks", func(config *ButtonConfig) *bool { return &config.multiClicks }},
+    {"pressRelease", func(config *ButtonConfig) *bool { return &config.pressRelease }},
+    {"clickHold", func(config *ButtonConfig) *bool { return &config.clickHold }},
+}
+
+var configUint8Fields = []struct {
+    key string
+    ptr func(config *ButtonConfig) *uint8
+} {
+    {"filterSize", func(config *ButtonConfig) *uint8 { return &config.filterSize }},
+    {"actFinishCnt", func(config *ButtonConfig) *uint8 { return &config.actFinishCnt }},
+    {"repeatDetectCnt", func(config *ButtonConfig) *uint8 { return &config.repeatDetectCnt }},
+    {"repeatSkip", func(config *ButtonConfig) *uint8 { return &config.repeatSkip }},
+    {"longDetectCnt", func(config *ButtonConfig) *uint8 { return &config.longDetectCnt }},
+    {"longLongDetectCnt", func(config *ButtonConfig) *uint8 { return &config.longLongDetectCnt }},
+}
+
+const (
+    configKeyRepeatProfile = "repeatProfile"
+    configKeyHoldTiers     = "holdTiers"
+)
+
+// MarshalJSON encodes config as a JSON object of its counts and flags (durations of NewButtonConfigByTiming are not kept)
+func (config *ButtonConfig) MarshalJSON() ([]byte, error) {
+    items := []string{}
+    for _, field := range configBoolFields {
+        items = append(items, quoteJSON(field.key) + ":" + strconv.FormatBool(*field.ptr(config)))
+    }
+    for _, field := range configUint8Fields {
+        items = append(items, quoteJSON(field.key) + ":" + strconv.Itoa(int(*field.ptr(config))))
+    }
+    if len(config.repeatProfile) > 0 {
+        stages := []string{}
+        for _, stage := range config.repeatProfile {
+            stages = append(stages, fmt.Sprintf("{\"afterCnt\":%d,\"skip\":%d}", stage.AfterCnt, stage.Skip))
+        }
+        items = append(items, quoteJSON(configKeyRepeatProfile) + ":[" + strings.Join(stages, ",") + "]")
+    }
+    if len(config.holdTiers) > 0 {
+        items = append(items, quoteJSON(configKeyHoldTiers) + ":[" + joinUint8(config.holdTiers, ",") + "]")
+    }
+    return []byte("{" + strings.Join(items, ",") + "}"), nil
+}
+
+// UnmarshalJSON decodes config from JSON made by MarshalJSON. Missing keys are zero, and the values are revised as NewButtonConfig() does
+func (config *ButtonConfig) UnmarshalJSON(data []byte) error {
+    value, err := parseJSON(data)
+    if err != nil {
+        return err
+    }
+    object, err := jsonToObject("ButtonConfig", value)
+    if err != nil {
+        return err
+    }
+    newConfig, err := configFromJSON(object)
+    if err != nil {
+        return err
+    }
+    *config = *newConfig
+    return nil
+}
+
+func configFromJSON(object map[string]interface{}) (*ButtonConfig, error) {
+    config := &ButtonConfig{}
+    for key, value := range object {
+        if ptr := boolFieldOf(config, key); ptr != nil {
+            flag, err := jsonToBool(key, value)
+            if err != nil {
+                return nil, err
+            }
+            *ptr = flag
+        } else if ptr := uint8FieldOf(config, key); ptr != nil {
+            cnt, err := jsonToUint8(key, value)
+            if err != nil {
+                return nil, err
+            }
+            *ptr = cnt
+        } else if key == configKeyRepeatProfile {
+            array, err := jsonToArray(key, value)
+            if err != nil {
+                return nil, err
+            }
+            for _, item := range array {
+                stageObject, err := jsonToObject(key, item)
+                if err != nil {
+                    return nil, err
+                }
+                afterCnt, err := jsonToUint8(key + ".afterCnt", stageObject["afterCnt"])
+                if err != nil {
+                    return nil, err
+                }
+                skip, err := jsonToUint8(key + ".skip", stageObject["skip"])
+                if err != nil {
+                    return nil, err
+                }
+                config.repeatProfile = append(config.repeatProfile, RepeatStage{AfterCnt: afterCnt, Skip: skip})
+            }
+        } else if key == configKeyHoldTiers {
+            array, err := jsonToArray(key, value)
+            if err != nil {
+                return nil, err
+            }
+            for _, item := range array {
+                cnt, err := jsonToUint8(key, item)
+                if err != nil {
+                    return nil, err
+                }
+                config.holdTiers = append(config.holdTiers, cnt)
+            }
+        } else {
+            return nil, fmt.Errorf("unknown key of ButtonConfig: %s", key)
+        }
+    }
+    config.reflectConstraints()
+    return config, nil
+}
+
+// MarshalText encodes config as space separated key=value, e.g. "activeHigh=false ... holdTiers=15,39 repeatProfile=20:1,40:0"
+func (config *ButtonConfig) MarshalText() ([]byte, error) {
+    return []byte(strings.Join(config.textFields(), " ")), nil
+}
+
+// UnmarshalText decodes config from text made by MarshalText. Missing keys are zero, and the values are revised as NewButtonConfig() does
+func (config *ButtonConfig) UnmarshalText(text []byte) error {
+    newConfig, err := configFromText(strings.Fields(string(text)))
+    if err != nil {
+        return err
+    }
+    *config = *newConfig
+    return nil
+}
+
+func (config *ButtonConfig) textFields() []string {
+    fields := []string{}
+    for _, field := range configBoolFields {
+        fields = append(fields, field.key + "=" + strconv.FormatBool(*field.ptr(config)))
+    }
+    for _, field := range configUint8Fields {
+        fields = append(fields, field.key + "=" + strconv.Itoa(int(*field.ptr(config))))
+    }
+    if len(config.repeatProfile) > 0 {
+        stages := []string{}
+        for _, stage := range config.repeatProfile {
+            stages = append(stages, fmt.Sprintf("%d:%d", stage.AfterCnt, stage.Skip))
+        }
+        fields = append(fields, configKeyRepeatProfile + "=" + strings.Join(stages, ","))
+    }
+    if len(config.holdTiers) > 0 {
+        fields = append(fields, configKeyHoldTiers + "=" + joinUint8(config.holdTiers, ","))
+    }
+    return fields
+}
+
+func configFromText(fields []string) (*ButtonConfig, error) {
+    config := &ButtonConfig{}
+    for _, field := range fields {
+        key, value, ok := strings.Cut(field, "=")
+        if !ok {
+            return nil, fmt.Errorf("key=value expected: %s", field)
+        }
+        if ptr := boolFieldOf(config, key); ptr != nil {
+            flag, err := strconv.ParseBool(value)
+            if err != nil {
+                return nil, fmt.Errorf("%s: bool expected", key)
+            }
+            *ptr = flag
+        } else if ptr := uint8FieldOf(config, key); ptr != nil {
+            cnt, err := parseUint8(key, value)
+            if err != nil {
+                return nil, err
+            }
+            *ptr = cnt
+        } else if key == configKeyRepeatProfile {
+            for _, item := range strings.Split(value, ",") {
+                afterCntStr, skipStr, ok := strings.Cut(item, ":")
+                if !ok {
+                    return nil, fmt.Errorf("%s: afterCnt:skip expected", key)
+                }
+                afterCnt, err := parseUint8(key, afterCntStr)
+                if err != nil {
+                    return nil, err
+                }
+                skip, err := parseUint8(key, skipStr)
+                if err != nil {
+                    return nil, err
+                }
+                config.repeatProfile = append(config.repeatProfile, RepeatStage{AfterCnt: afterCnt, Skip: skip})
+            }
+        } else if key == configKeyHoldTiers {
+            for _, item := range strings.Split(value, ",") {
+                cnt, err := parseUint8(key, item)
+                if err != nil {
+                    return nil, err
+                }
+                config.holdTiers = append(config.holdTiers, cnt)
+            }
+        } else {
+            return nil, fmt.Errorf("unknown key of ButtonConfig: %s", key)
+        }
+    }
+    config.reflectConstraints()
+    return config, nil
+}
+
+func boolFieldOf(config *ButtonConfig, key string) *bool {
+    for _, field := range configBoolFields {
+        if field.key == key {
+            return field.ptr(config)
+        }
+    }
+    return nil
+}
+
+func uint8FieldOf(config *ButtonConfig, key string) *uint8 {
+    for _, field := range configUint8Fields {
+        if field.key == key {
+            return field.ptr(config)
+        }
+    }
+    return nil
+}
+
+func parseUint8(key, s string) (uint8, error) {
+    cnt, err := strconv.ParseUint(s, 10, 8)
+    if err != nil {
+        return 0, fmt.Errorf("%s: integer 0 to 255 expected", key)
+    }
+    return uint8(cnt), nil
+}
+
+func joinUint8(cnts []uint8, sep string) string {
+    items := []string{}
+    for _, cnt := range cnts {
+        items = append(items, strconv.Itoa(int(cnt)))
+    }
+    return strings.Join(items, sep)
+}
+
+// equalConfig compares counts and flags of configs
+func equalConfig(a, b *ButtonConfig) bool {
+    if a == b {
+        return true
+    }
+    for _, field := range configBoolFields {
+        if *field.ptr(a) != *field.ptr(b) {
+            return false
+        }
+    }
+    for _, field := range configUint8Fields {
+        if *field.ptr(a) != *field.ptr(b) {
+            return false
+        }
+    }
+    if len(a.repeatProfile) != len(b.repeatProfile) || len(a.holdTiers) != len(b.holdTiers) {
+        return false
+    }
+    for i := range a.repeatProfile {
+        if a.repeatProfile[i] != b.repeatProfile[i] {
+            return false
+        }
+    }
+    for i := range a.holdTiers {
+        if a.holdTiers[i] != b.holdTiers[i] {
+            return false
+        }
+    }
+    return true
+}
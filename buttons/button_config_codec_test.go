@@ -0,0 +1,92 @@
+package buttons
+
+import (
+    "testing"
+)
+
+// customConfig has every field of ButtonConfig set
+func customConfig() *ButtonConfig {
+    return NewButtonConfig(true, true, 2, 6, 0, 0, 0, 0).
+        WithClickHold(true).
+        WithPressRelease(true).
+        WithHoldTiers(15, 39, 100)
+}
+
+func customRepeatConfig() *ButtonConfig {
+    return DefaultButtonSingleRepeatConfig.WithRepeatProfile(
+        RepeatStage{AfterCnt: 20, Skip: 1},
+        RepeatStage{AfterCnt: 40, Skip: 0},
+    )
+}
+
+func testConfigs() map[string]*ButtonConfig {
+    configs := map[string]*ButtonConfig {
+        "custom": customConfig(),
+        "customRepeat": customRepeatConfig(),
+    }
+    for _, preset := range presets {
+        configs[preset.name] = preset.config
+    }
+    return configs
+}
+
+func TestConfigJSONRoundTrip(t *testing.T) {
+    for name, config := range testConfigs() {
+        data, err := config.MarshalJSON()
+        if err != nil {
+            t.Fatalf("%s: %s", name, err)
+        }
+        decoded := &ButtonConfig{}
+        if err := decoded.UnmarshalJSON(data); err != nil {
+            t.Fatalf("%s: %s", name, err)
+        }
+        if !equalConfig(config, decoded) {
+            t.Fatalf("%s: %s decoded differently", name, data)
+        }
+    }
+}
+
+func TestConfigTextRoundTrip(t *testing.T) {
+    for name, config := range testConfigs() {
+        text, err := config.MarshalText()
+        if err != nil {
+            t.Fatalf("%s: %s", name, err)
+        }
+        decoded := &ButtonConfig{}
+        if err := decoded.UnmarshalText(text); err != nil {
+            t.Fatalf("%s: %s", name, err)
+        }
+        if !equalConfig(config, decoded) {
+            t.Fatalf("%s: %s decoded differently", name, text)
+        }
+    }
+}
+
+func TestConfigMalformed(t *testing.T) {
+    for _, json := range []string {
+        `[]`,
+        `{"filterSize":256}`,
+        `{"filterSize":1.5}`,
+        `{"filterSize":"1"}`,
+        `{"activeHigh":1}`,
+        `{"repeatProfile":{}}`,
+        `{"repeatProfile":[{"afterCnt":-1,"skip":0}]}`,
+        `{"holdTiers":[300]}`,
+    } {
+        if err := (&ButtonConfig{}).UnmarshalJSON([]byte(json)); err == nil {
+            t.Fatalf("%s: no error", json)
+        }
+    }
+    for _, text := range []string {
+        `filterSize`,
+        `filterSize=256`,
+        `activeHigh=yes`,
+        `repeatProfile=20`,
+        `repeatProfile=20:x`,
+        `holdTiers=15,,39`,
+    } {
+        if err := (&ButtonConfig{}).UnmarshalText([]byte(text)); err == nil {
+            t.Fatalf("%s: no error", text)
+        }
+    }
+}
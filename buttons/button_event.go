@@ -7,6 +7,16 @@ const (
     EVT_MULTI
     EVT_LONG
     EVT_LONG_LONG
+    EVT_PRESS
+    EVT_RELEASE
+    EVT_CHORD
+    EVT_SEQUENCE
+    EVT_OVERFLOW
+    EVT_CLICK_HOLD
+    EVT_LONG_RELEASE
+    EVT_HOLD
+    EVT_ROTATE
+    EVT_CHORD_RELEASE
 )
 
 type ButtonEvent struct {
@@ -14,4 +24,22 @@ type ButtonEvent struct {
     Type        ButtonEventType
     ClickCount  uint8
     RepeatCount uint8
+    HoldCount   uint16 // scans the button was held (EVT_LONG_RELEASE) or the chord was in progress (EVT_CHORD_RELEASE)
+    Tier        uint8  // index of hold tier (EVT_LONG: 0, EVT_LONG_LONG: 1, EVT_HOLD: 2 or more)
+    Delta       int16  // detents rotated with acceleration, positive for A leading B (EVT_ROTATE)
+    ScanCount   uint32 // scan count of Buttons when the event was detected
+    Timestamp   uint64 // time in microseconds when the event was detected (0 if no clock is set)
+    Sequence    uint32 // serial number per Buttons, incremented even if the event is dropped
+}
+
+// holdTierEventType returns EVT_LONG and EVT_LONG_LONG for the first two tiers to keep compatibility, EVT_HOLD for the others
+func holdTierEventType(tier int) ButtonEventType {
+    switch tier {
+    case 0:
+        return EVT_LONG
+    case 1:
+        return EVT_LONG_LONG
+    default:
+        return EVT_HOLD
+    }
 }
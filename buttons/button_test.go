@@ -0,0 +1,43 @@
+package buttons
+
+import (
+    "testing"
+)
+
+type testBank struct {
+    levels uint32
+}
+
+func (bank *testBank) Get() uint32 {
+    return bank.levels
+}
+
+func TestNewBankButtonRange(t *testing.T) {
+    if _, err := NewBankButton("x", 32, DefaultButtonSingleConfig); err == nil {
+        t.Fatal("no error for bit 32")
+    }
+    if _, err := NewBankButton("x", 40, DefaultButtonSingleConfig); err == nil {
+        t.Fatal("no error for bit 40")
+    }
+}
+
+func TestBankButton(t *testing.T) {
+    bank := &testBank{^uint32(0)}
+    buttons := New("test")
+    for _, bit := range []uint8{0, 31} {
+        button, err := NewBankButton(string(rune('a' + bit % 26)), bit, DefaultButtonSingleConfig)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if err := buttons.AddButton(button); err != nil {
+            t.Fatal(err)
+        }
+    }
+    buttons.SetPinBank(bank)
+    scanN(buttons, 1)
+    bank.levels &^= 1 << 31
+    scanN(buttons, 1)
+    checkEvents(t, drainTestEvents(buttons), []testEvent {
+        {"f", EVT_SINGLE, 1},
+    })
+}
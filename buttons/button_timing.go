@@ -0,0 +1,115 @@
+package buttons
+
+import (
+    "time"
+)
+
+// ButtonTiming expresses the counts of ButtonConfig in durations instead of scans
+type ButtonTiming struct {
+    Filter         time.Duration // duration to keep raw status to process filtered status
+    ActFinish      time.Duration // Button action detection starts when status keeps released for this duration (only if multiClicks)
+    RepeatDetect   time.Duration // continuous push duration to detect Repeat click (ignored if 0)
+    RepeatInterval time.Duration // interval of Repeat click detection (every scan if 0)
+    Long           time.Duration // continuous push duration to detect Long Push (ignored if 0)
+    LongLong       time.Duration // continuous push duration to detect LongLong Push (ignored if 0)
+    HoldTiers      []time.Duration // continuous push durations to detect each hold tier, overrides Long/LongLong if not empty
+    RepeatProfile  []RepeatTiming  // stages to change RepeatInterval while continuous push
+}
+
+// RepeatTiming changes interval of Repeat click detection after continuous push of After since Repeat started (RepeatStage in durations)
+type RepeatTiming struct {
+    After    time.Duration
+    Interval time.Duration
+}
+
+// NewButtonConfigByTiming converts timing into counts of scanPeriod (rounded to nearest, at least 1 scan for non-zero durations).
+// Use Timing() of the returned config to see the effective durations
+func NewButtonConfigByTiming(activeHigh, multiClicks bool, timing ButtonTiming, scanPeriod time.Duration) *ButtonConfig {
+    config := &ButtonConfig {
+        activeHigh: activeHigh,
+        multiClicks: multiClicks,
+    }
+    timing.HoldTiers = append([]time.Duration{}, timing.HoldTiers...)
+    timing.RepeatProfile = append([]RepeatTiming{}, timing.RepeatProfile...)
+    config.timing = &timing
+    config.applyTiming(scanPeriod)
+    return config
+}
+
+// Timing returns the effective durations of config scanned every scanPeriod
+func (config *ButtonConfig) Timing(scanPeriod time.Duration) ButtonTiming {
+    var holdTiers []time.Duration
+    for _, cnt := range config.holdTiers {
+        holdTiers = append(holdTiers, time.Duration(cnt) * scanPeriod)
+    }
+    var repeatProfile []RepeatTiming
+    for _, stage := range config.repeatProfile {
+        repeatProfile = append(repeatProfile, RepeatTiming {
+            After: time.Duration(stage.AfterCnt) * scanPeriod,
+            Interval: (time.Duration(stage.Skip) + 1) * scanPeriod,
+        })
+    }
+    return ButtonTiming {
+        Filter: time.Duration(config.filterSize) * scanPeriod,
+        ActFinish: time.Duration(config.actFinishCnt) * scanPeriod,
+        RepeatDetect: time.Duration(config.repeatDetectCnt) * scanPeriod,
+        RepeatInterval: (time.Duration(config.repeatSkip) + 1) * scanPeriod,
+        Long: time.Duration(config.longDetectCnt) * scanPeriod,
+        LongLong: time.Duration(config.longLongDetectCnt) * scanPeriod,
+        HoldTiers: holdTiers,
+        RepeatProfile: repeatProfile,
+    }
+}
+
+// rescaled returns a copy of config whose counts are re-derived for scanPeriod (config itself if not made by timing)
+func (config *ButtonConfig) rescaled(scanPeriod time.Duration) *ButtonConfig {
+    if config.timing == nil {
+        return config
+    }
+    newConfig := *config
+    newConfig.applyTiming(scanPeriod)
+    return &newConfig
+}
+
+func (config *ButtonConfig) applyTiming(scanPeriod time.Duration) {
+    timing := config.timing
+    config.filterSize = durationToCnt(timing.Filter, scanPeriod)
+    config.actFinishCnt = durationToCnt(timing.ActFinish, scanPeriod)
+    config.repeatDetectCnt = durationToCnt(timing.RepeatDetect, scanPeriod)
+    config.repeatSkip = intervalToSkip(timing.RepeatInterval, scanPeriod)
+    config.repeatProfile = nil
+    for _, stage := range timing.RepeatProfile {
+        config.repeatProfile = append(config.repeatProfile, RepeatStage {
+            AfterCnt: durationToCnt(stage.After, scanPeriod),
+            Skip: intervalToSkip(stage.Interval, scanPeriod),
+        })
+    }
+    config.longDetectCnt = durationToCnt(timing.Long, scanPeriod)
+    config.longLongDetectCnt = durationToCnt(timing.LongLong, scanPeriod)
+    config.holdTiers = nil
+    for _, duration := range timing.HoldTiers {
+        config.holdTiers = append(config.holdTiers, durationToCnt(duration, scanPeriod))
+    }
+    config.reflectConstraints()
+}
+
+func durationToCnt(duration, scanPeriod time.Duration) uint8 {
+    if duration <= 0 || scanPeriod <= 0 {
+        return 0
+    }
+    cnt := (duration + scanPeriod / 2) / scanPeriod
+    if cnt < 1 {
+        cnt = 1
+    } else if cnt > historyMaxCnt {
+        cnt = historyMaxCnt
+    }
+    return uint8(cnt)
+}
+
+// intervalToSkip converts interval of Repeat click detection into skip count (every scan if 0)
+func intervalToSkip(interval, scanPeriod time.Duration) uint8 {
+    if cnt := durationToCnt(interval, scanPeriod); cnt > 0 {
+        return cnt - 1
+    }
+    return 0
+}
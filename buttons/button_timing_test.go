@@ -0,0 +1,128 @@
+package buttons
+
+import (
+    "testing"
+    "time"
+)
+
+func equalCnts(a, b []uint8) bool {
+    if len(a) != len(b) {
+        return false
+    }
+    for i := range a {
+        if a[i] != b[i] {
+            return false
+        }
+    }
+    return true
+}
+
+func TestTimingWithHoldTiers(t *testing.T) {
+    timing := ButtonTiming {
+        Filter: 10 * time.Millisecond,
+        Long: 1 * time.Second,
+        LongLong: 2 * time.Second,
+    }
+    config := NewButtonConfigByTiming(false, false, timing, 10 * time.Millisecond).WithHoldTiers(10, 20, 30)
+    want := []uint8{10, 20, 30}
+    if holdTiers := config.rescaled(50 * time.Millisecond).holdTiers; !equalCnts(holdTiers, want) {
+        t.Fatalf("hold tiers %v after rescaled, want %v", holdTiers, want)
+    }
+    buttons := New("test", NewButton("a", &testPin{true}, config))
+    buttons.SetScanPeriod(50 * time.Millisecond)
+    scanN(buttons, 1)
+    if holdTiers := buttons.findButton("a").config.holdTiers; !equalCnts(holdTiers, want) {
+        t.Fatalf("hold tiers %v after SetScanPeriod, want %v", holdTiers, want)
+    }
+}
+
+func TestTimingRepeatProfile(t *testing.T) {
+    timing := ButtonTiming {
+        Filter: 10 * time.Millisecond,
+        RepeatDetect: 500 * time.Millisecond,
+        RepeatInterval: 150 * time.Millisecond,
+        RepeatProfile: []RepeatTiming {
+            {After: 1 * time.Second, Interval: 100 * time.Millisecond},
+            {After: 2 * time.Second, Interval: 50 * time.Millisecond},
+        },
+    }
+    config := NewButtonConfigByTiming(false, false, timing, 50 * time.Millisecond)
+    for _, scanPeriod := range []time.Duration{50 * time.Millisecond, 25 * time.Millisecond, 10 * time.Millisecond} {
+        rescaled := config.rescaled(scanPeriod)
+        got := rescaled.Timing(scanPeriod)
+        if len(got.RepeatProfile) != len(timing.RepeatProfile) {
+            t.Fatalf("%v: repeat profile %v", scanPeriod, got.RepeatProfile)
+        }
+        for i := range got.RepeatProfile {
+            if got.RepeatProfile[i] != timing.RepeatProfile[i] {
+                t.Fatalf("%v: stage %d %v, want %v", scanPeriod, i, got.RepeatProfile[i], timing.RepeatProfile[i])
+            }
+        }
+    }
+    // counts given by WithRepeatProfile are kept
+    stage := RepeatStage{AfterCnt: 20, Skip: 1}
+    fixed := config.WithRepeatProfile(stage).rescaled(10 * time.Millisecond)
+    if len(fixed.repeatProfile) != 1 || fixed.repeatProfile[0] != stage {
+        t.Fatalf("repeat profile %v after rescaled, want %v", fixed.repeatProfile, stage)
+    }
+}
+
+func TestDurationToCnt(t *testing.T) {
+    const ms = time.Millisecond
+    for _, tc := range []struct {
+        duration   time.Duration
+        scanPeriod time.Duration
+        want       uint8
+    } {
+        {0, 10 * ms, 0},
+        {-5 * ms, 10 * ms, 0},
+        {100 * ms, 0, 0},
+        {1 * ms, 10 * ms, 1},   // at least 1 scan
+        {14 * ms, 10 * ms, 1},  // rounded to nearest
+        {15 * ms, 10 * ms, 2},
+        {1000 * ms, 10 * ms, 100},
+        {3000 * ms, 10 * ms, historyMaxCnt}, // clamped
+    } {
+        if got := durationToCnt(tc.duration, tc.scanPeriod); got != tc.want {
+            t.Fatalf("durationToCnt(%v, %v) = %d, want %d", tc.duration, tc.scanPeriod, got, tc.want)
+        }
+    }
+}
+
+func TestTimingRepeatIntervalMax(t *testing.T) {
+    config := NewButtonConfig(false, false, 1, 0, 10, 255, 0, 0)
+    if interval := config.Timing(time.Millisecond).RepeatInterval; interval != 256 * time.Millisecond {
+        t.Fatalf("RepeatInterval %v, want 256ms", interval)
+    }
+}
+
+func TestTimingSetScanPeriod(t *testing.T) {
+    timing := ButtonTiming {
+        Filter: 20 * time.Millisecond,
+        ActFinish: 250 * time.Millisecond,
+        Long: 1 * time.Second,
+        LongLong: 2 * time.Second,
+    }
+    config := NewButtonConfigByTiming(false, true, timing, 10 * time.Millisecond)
+    if config.filterSize != 2 || config.actFinishCnt != 25 || config.longDetectCnt != 100 || config.longLongDetectCnt != 200 {
+        t.Fatalf("counts %d %d %d %d at 10ms", config.filterSize, config.actFinishCnt, config.longDetectCnt, config.longLongDetectCnt)
+    }
+    buttons := New("test", NewButton("a", &testPin{true}, config))
+    buttons.SetScanPeriod(50 * time.Millisecond)
+    scanN(buttons, 1)
+    got := buttons.findButton("a").config
+    if got.filterSize != 1 || got.actFinishCnt != 5 || got.longDetectCnt != 20 || got.longLongDetectCnt != 40 {
+        t.Fatalf("counts %d %d %d %d at 50ms", got.filterSize, got.actFinishCnt, got.longDetectCnt, got.longLongDetectCnt)
+    }
+    if effective := got.Timing(50 * time.Millisecond); effective.Filter != 50 * time.Millisecond || effective.Long != timing.Long {
+        t.Fatalf("effective timing %v", effective)
+    }
+    // a button added later follows the scan period
+    if err := buttons.AddButton(NewButton("b", &testPin{true}, config)); err != nil {
+        t.Fatal(err)
+    }
+    scanN(buttons, 1)
+    if cnt := buttons.findButton("b").config.longDetectCnt; cnt != 20 {
+        t.Fatalf("longDetectCnt %d of added button at 50ms, want 20", cnt)
+    }
+}
@@ -1,121 +1,535 @@
 package buttons
 
-const ButtonEventChanSize = 16
+import (
+    "fmt"
+    "sync"
+    "sync/atomic"
+    "time"
+)
+
+const ButtonEventChanSize = 16 // capacity of event queue (must be a power of 2)
+
+// OverflowPolicy decides what to drop when the event queue is full
+type OverflowPolicy int
+const (
+    OVERFLOW_DROP_NEWEST OverflowPolicy = iota // drop the new event
+    OVERFLOW_DROP_OLDEST                       // drop the oldest event in the queue to push the new event
+    OVERFLOW_COALESCE                          // keep only the latest Repeat event per button until the queue has room, drop other new events
+)
 
 type Buttons struct {
-    name        string
-    buttonSlice []*Button
-    scanSkip    uint8
-    scanCnt     uint32
-    event       chan ButtonEvent
+    name            string
+    buttonSlice     atomic.Pointer[[]*Button]  // copied on write by main, loaded once a scan by ScanPeriodic
+    chords          atomic.Pointer[[]*Chord]   // copied on write by main, loaded once a scan by ScanPeriodic
+    encoders        atomic.Pointer[[]*Encoder] // copied on write by main, loaded once a scan by ScanPeriodic
+    samplers        atomic.Pointer[[]Sampler]  // copied on write by main, loaded once a scan by ScanPeriodic
+    mutex           sync.Mutex                 // serializes writers of buttonSlice, chords, encoders and samplers
+    bank            atomic.Pointer[PinBank]
+    scanSkip        atomic.Uint32
+    scanCnt         uint32
+    scanPeriod      time.Duration
+    clock           func() uint64
+    timestamp       uint64
+    sequence        uint32
+    queue           eventQueue
+    overflowPolicy  atomic.Uint32 // OverflowPolicy
+    overflowCnt     atomic.Uint32
+    overflowPending bool
+    handlers        []handlerEntry
+    handlerId       HandlerId
+    matchers        []*SequenceMatcher
+    idleScans       uint32
+    idleCnt         uint32
+    suspend         func()
+    resume          func() error
+    suspended       atomic.Bool
+    resumeErrCnt    atomic.Uint32
 }
 
 func New(name string, button ...*Button) *Buttons {
-    return &Buttons {
+    buttons := &Buttons {
         name: name,
-        buttonSlice: append([]*Button{}, button...),
-        event: make(chan ButtonEvent, ButtonEventChanSize),
     }
+    buttonSlice := append([]*Button{}, button...)
+    buttons.buttonSlice.Store(&buttonSlice)
+    buttons.chords.Store(&[]*Chord{})
+    buttons.encoders.Store(&[]*Encoder{})
+    buttons.samplers.Store(&[]Sampler{})
+    return buttons
+}
+
+func (buttons *Buttons) getButtons() []*Button {
+    return *buttons.buttonSlice.Load()
+}
+
+func (buttons *Buttons) getChords() []*Chord {
+    return *buttons.chords.Load()
+}
+
+func (buttons *Buttons) findButton(name string) *Button {
+    for _, button := range buttons.getButtons() {
+        if button.name == name {
+            return button
+        }
+    }
+    return nil
+}
+
+// SetPinBank sets PinBank for the buttons made by NewBankButton(), which are scanned from a single snapshot of bank every scan
+func (buttons *Buttons) SetPinBank(bank PinBank) {
+    if bank == nil {
+        buttons.bank.Store(nil)
+        return
+    }
+    buttons.bank.Store(&bank)
+}
+
+// AddButton adds button to live Buttons, it is safe while ScanPeriodic is running
+func (buttons *Buttons) AddButton(button *Button) error {
+    buttons.mutex.Lock()
+    defer buttons.mutex.Unlock()
+    if buttons.findButton(button.name) != nil {
+        return fmt.Errorf("button %s already exists", button.name)
+    }
+    if buttons.scanPeriod > 0 && button.config.timing != nil {
+        button.pending.Store(newButtonUpdate(button.config.rescaled(buttons.scanPeriod)))
+    }
+    buttonSlice := append(append([]*Button{}, buttons.getButtons()...), button)
+    buttons.buttonSlice.Store(&buttonSlice)
+    return nil
+}
+
+// RemoveButton removes the button of name from live Buttons, it is safe while ScanPeriodic is running.
+// The button used by a chord cannot be removed
+func (buttons *Buttons) RemoveButton(name string) error {
+    buttons.mutex.Lock()
+    defer buttons.mutex.Unlock()
+    for _, chord := range buttons.getChords() {
+        for _, member := range chord.members {
+            if member.name == name {
+                return fmt.Errorf("button %s is used by chord %s", name, chord.name)
+            }
+        }
+    }
+    buttonSlice := []*Button{}
+    for _, button := range buttons.getButtons() {
+        if button.name != name {
+            buttonSlice = append(buttonSlice, button)
+        }
+    }
+    if len(buttonSlice) == len(buttons.getButtons()) {
+        return fmt.Errorf("button %s not found", name)
+    }
+    buttons.buttonSlice.Store(&buttonSlice)
+    return nil
+}
+
+// DisableButton masks events of the button of name (and chords including it) while keeping its scan going.
+// It is safe while ScanPeriodic is running
+func (buttons *Buttons) DisableButton(name string) error {
+    return buttons.setButtonEnabled(name, false)
+}
+
+// EnableButton unmasks events of the button of name. The button keeps silent until released if it is pushed at that time
+func (buttons *Buttons) EnableButton(name string) error {
+    return buttons.setButtonEnabled(name, true)
+}
+
+func (buttons *Buttons) setButtonEnabled(name string, enabled bool) error {
+    button := buttons.findButton(name)
+    if button == nil {
+        return fmt.Errorf("button %s not found", name)
+    }
+    button.disabled.Store(!enabled)
+    return nil
 }
 
+// SetScanSkip sets the number of scans to skip from the start, it is safe while ScanPeriodic is running
 func (buttons *Buttons) SetScanSkip(scanSkip uint8) {
-    buttons.scanSkip = scanSkip
+    buttons.scanSkip.Store(uint32(scanSkip))
+}
+
+// SetScanPeriod re-derives counts of the buttons whose ButtonConfig is made by NewButtonConfigByTiming.
+// It is safe while ScanPeriodic is running in the same way as SetConfig()
+func (buttons *Buttons) SetScanPeriod(scanPeriod time.Duration) {
+    buttons.scanPeriod = scanPeriod
+    for _, button := range buttons.getButtons() {
+        if config := button.latestConfig(); config.timing != nil {
+            button.pending.Store(newButtonUpdate(config.rescaled(scanPeriod)))
+        }
+    }
+}
+
+// SetConfig replaces ButtonConfig of the button of name. It is safe while ScanPeriodic is running (e.g. in timer interrupt),
+// where ScanPeriodic applies it at the next scan and resets history and detection status of the button not to cause phantom events.
+// The button keeps silent until released if it is pushed at that time
+func (buttons *Buttons) SetConfig(name string, config *ButtonConfig) error {
+    button := buttons.findButton(name)
+    if button == nil {
+        return fmt.Errorf("button %s not found", name)
+    }
+    if buttons.scanPeriod > 0 {
+        config = config.rescaled(buttons.scanPeriod)
+    }
+    button.pending.Store(newButtonUpdate(config))
+    return nil
+}
+
+func (buttons *Buttons) GetScanPeriod() time.Duration {
+    return buttons.scanPeriod
+}
+
+// SetClock sets the clock function returning time in microseconds (e.g. mymachine.TimeElapsed) to stamp events
+func (buttons *Buttons) SetClock(clock func() uint64) {
+    buttons.clock = clock
+}
+
+func (buttons *Buttons) SetOverflowPolicy(policy OverflowPolicy) {
+    buttons.overflowPolicy.Store(uint32(policy))
+}
+
+// GetOverflowCount returns the number of events dropped by the full event queue
+func (buttons *Buttons) GetOverflowCount() uint32 {
+    return buttons.overflowCnt.Load()
 }
 
 func (buttons *Buttons) GetName() string {
     return buttons.name
 }
 
+// GetEvent returns the oldest event in the queue, nil if none
 func (buttons *Buttons) GetEvent() *ButtonEvent {
-    if len(buttons.event) == 0 {
-        return nil
-    }
-    event, more := <- buttons.event
-    if !more {
+    var event ButtonEvent
+    if !buttons.queue.pop(&event) {
         return nil
     }
     return &event
 }
 
+// DrainEvents moves events in the queue to dst in order up to len(dst), returns the number of events moved
+func (buttons *Buttons) DrainEvents(dst []ButtonEvent) (count int) {
+    for count < len(dst) && buttons.queue.pop(&dst[count]) {
+        count++
+    }
+    return count
+}
+
 func ScanPeriodic(buttons *Buttons) {
     defer func() { buttons.scanCnt++ } ()
-    if buttons.scanCnt < uint32(buttons.scanSkip) {
+    if buttons.scanCnt < buttons.scanSkip.Load() {
         return
     }
-    for _, button := range buttons.buttonSlice {
-        // what to get (default values)
-        var repeatCnt, countRise uint8
-        var detectLong, detectLongLong bool
-        // alias
-        cfg := button.config
-        // === get raw status of pin ===
-        rawSts := button.pin.Get() == cfg.activeHigh
-        // === unshift history ===
-        button.history.unshift(rawSts)
-        recentStayPushedCounts := button.history.recentStayPushedCounts()
-        recentStayReleasedCounts :=button.history.recentStayReleasedCounts()
-        // === Detect Repeated (by non-filtered) ===
-        if cfg.longDetectCnt == 0 && cfg.longLongDetectCnt == 0 {
-            if buttons.scanCnt % uint32(cfg.repeatSkip + 1) == 0 {
-                if cfg.repeatDetectCnt > 0 && recentStayPushedCounts >= cfg.repeatDetectCnt {
-                    if button.rptCnt < 255 {
-                        button.rptCnt++
-                    }
-                    repeatCnt = button.rptCnt
-                } else {
-                    button.rptCnt = 0
-                }
+    if buttons.clock != nil {
+        buttons.timestamp = buttons.clock()
+    }
+    buttons.flushPending()
+    buttonSlice := buttons.getButtons()
+    for _, sampler := range buttons.getSamplers() {
+        sampler.Sample()
+    }
+    // read all inputs of bank at once
+    var snapshot uint32
+    bank := buttons.bank.Load()
+    if bank != nil {
+        snapshot = (*bank).Get()
+    }
+    // sample all buttons before detection so that chords see the status of the same scan
+    for _, button := range buttonSlice {
+        button.sample(snapshot, bank != nil)
+    }
+    buttons.scanChords()
+    for _, button := range buttonSlice {
+        buttons.detect(button)
+    }
+    buttons.scanEncoders()
+    buttons.checkIdle()
+}
+
+func (button *Button) sample(snapshot uint32, hasBank bool) {
+    // apply update at the scan boundary
+    if update := button.pending.Swap(nil); update != nil {
+        button.applyUpdate(update)
+    }
+    // alias
+    cfg := button.config
+    // === unshift history ===
+    button.history.unshift(button.rawPushed(snapshot, hasBank))
+    recentStayPushedCounts := button.history.recentStayPushedCounts()
+    recentStayReleasedCounts := button.history.recentStayReleasedCounts()
+    // === Update Pressed status (by filtered) ===
+    button.lastPressed = button.pressed
+    if recentStayPushedCounts >= cfg.filterSize {
+        button.pressed = true
+    } else if recentStayReleasedCounts >= cfg.filterSize {
+        button.pressed = false
+    }
+    // === Count scans held ===
+    if button.pressed {
+        if !button.lastPressed {
+            button.holdCnt = 0
+        }
+        if button.holdCnt < 65535 {
+            button.holdCnt++
+        }
+    }
+}
+
+// rawPushed returns raw status of pin (or bank)
+func (button *Button) rawPushed(snapshot uint32, hasBank bool) bool {
+    var level bool
+    if button.bankBit < 0 {
+        level = button.pin.Get()
+    } else if hasBank {
+        level = (snapshot >> button.bankBit) & 1 != 0
+    } else {
+        // regard as released without bank
+        level = !button.config.activeHigh
+    }
+    return level == button.config.activeHigh
+}
+
+func (buttons *Buttons) detect(button *Button) {
+    // what to get (default values)
+    var repeatCnt, countRise uint8
+    var detectTiers uint32 // bit i is set if hold tier i detected
+    var holdClickCnt uint8
+    // alias
+    cfg := button.config
+    recentStayPushedCounts := button.history.recentStayPushedCounts()
+    recentStayReleasedCounts := button.history.recentStayReleasedCounts()
+    // === Check muted (member of chord in progress or disabled) ===
+    disabled := button.disabled.Load()
+    if button.wasDisabled && !disabled && button.pressed {
+        // keep silent until released if pushed when enabled
+        button.muted = true
+    }
+    button.wasDisabled = disabled
+    muted := button.muted || disabled
+    longRelease := button.longFired && !button.pressed
+    if !button.pressed {
+        button.muted = false
+        button.clickHeld = false
+        button.longFired = false
+    }
+    // === Detect Repeated (by non-filtered) ===
+    if cfg.repeatDetectCnt > 0 && recentStayPushedCounts >= cfg.repeatDetectCnt {
+        if button.rptWait > 0 {
+            button.rptWait--
+        } else {
+            if button.rptCnt < 255 {
+                button.rptCnt++
             }
+            repeatCnt = button.rptCnt
+            button.rptWait = cfg.repeatSkipAt(button.rptScans)
         }
-        // === Detect Long (by non-filtered) ===
-        if cfg.repeatDetectCnt == 0 {
-            if recentStayPushedCounts > 0 {
-                if recentStayPushedCounts == cfg.longDetectCnt {
-                    detectLong = true
-                } else if recentStayPushedCounts == cfg.longLongDetectCnt {
-                    detectLongLong = true
-                }
+        if button.rptScans < 65535 {
+            button.rptScans++
+        }
+    } else {
+        button.rptCnt = 0
+        button.rptWait = 0
+        button.rptScans = 0
+    }
+    // === Detect Hold tiers (Long, LongLong, ...) (by non-filtered) ===
+    if recentStayPushedCounts > 0 {
+        for tier := 0; tier < cfg.holdTierNum(); tier++ {
+            // tiers after Click-then-Hold are not detected
+            if recentStayPushedCounts == cfg.holdTierCnt(tier) && (tier == 0 || !button.clickHeld) {
+                detectTiers |= 1 << tier
             }
         }
-        // === unshift Filter ===
-        if recentStayPushedCounts >= cfg.filterSize {
-            button.filtered.unshift(true)
-        } else if recentStayReleasedCounts >= cfg.filterSize {
-            button.filtered.unshift(false)
+    }
+    detectLong := detectTiers & 1 != 0
+    // === Detect Press/Release (by filtered) ===
+    edgeType := EVT_NONE
+    if cfg.pressRelease && button.pressed != button.lastPressed {
+        if button.pressed {
+            edgeType = EVT_PRESS
         } else {
-            button.filtered.unshift(button.filtered.getPos(0))
+            edgeType = EVT_RELEASE
         }
-        recentStayReleasedCountsFiltered := button.filtered.recentStayReleasedCounts()
-        // === Check Action finished (only if multiClicks) ===
-        actFinished := recentStayReleasedCountsFiltered >= cfg.actFinishCnt
-        // === Then, Count rising edge ===
-        if repeatCnt > 0 { // if repeatCnt,countRise could be 0
-            countRise = 1
-        } else if actFinished {
-            countRise = button.filtered.countRisingEdge(!cfg.multiClicks)
+    }
+    // === unshift Filter ===
+    if recentStayPushedCounts >= cfg.filterSize {
+        button.filtered.unshift(true)
+    } else if recentStayReleasedCounts >= cfg.filterSize {
+        button.filtered.unshift(false)
+    } else {
+        button.filtered.unshift(button.filtered.getPos(0))
+    }
+    // === Detect Click-then-Hold (only if multiClicks) ===
+    if detectLong && cfg.multiClicks && cfg.clickHold {
+        // rising edges include the one of current push
+        if countHold := button.filtered.countRisingEdge(false); countHold > 1 {
+            holdClickCnt = countHold - 1
+            detectTiers &^= 1
+            button.clickHeld = true
         }
-        // Clear all once detected, initialize all as true to avoid repeated detection
-        if detectLong || countRise > 0 {
-            button.filtered = newHistory(true)
+    }
+    recentStayReleasedCountsFiltered := button.filtered.recentStayReleasedCounts()
+    // === Check Action finished (only if multiClicks) ===
+    actFinished := recentStayReleasedCountsFiltered >= cfg.actFinishCnt
+    // === Then, Count rising edge ===
+    if repeatCnt > 0 { // if repeatCnt,countRise could be 0
+        countRise = 1
+    } else if actFinished {
+        countRise = button.filtered.countRisingEdge(!cfg.multiClicks)
+    }
+    // Clear all once detected, initialize all as true to avoid repeated detection
+    if detectLong || holdClickCnt > 0 || countRise > 0 || muted {
+        button.filtered.fill(true)
+    }
+    if muted {
+        return
+    }
+    // === Send event (in order of Press/Release, Long Release, Click-then-Hold, Hold tiers, then Single/Multi including Repeat) ===
+    if edgeType != EVT_NONE {
+        buttons.sendButtonEvent(button, ButtonEvent {
+            ButtonName: button.name,
+            Type: edgeType,
+        })
+    }
+    if longRelease {
+        buttons.sendButtonEvent(button, ButtonEvent {
+            ButtonName: button.name,
+            Type: EVT_LONG_RELEASE,
+            HoldCount: button.holdCnt,
+        })
+    }
+    if holdClickCnt > 0 || detectTiers != 0 {
+        button.longFired = true
+    }
+    if holdClickCnt > 0 {
+        buttons.sendButtonEvent(button, ButtonEvent {
+            ButtonName: button.name,
+            Type: EVT_CLICK_HOLD,
+            ClickCount: holdClickCnt,
+        })
+    }
+    for tier := 0; detectTiers >> tier != 0; tier++ {
+        if detectTiers & (1 << tier) != 0 {
+            buttons.sendButtonEvent(button, ButtonEvent {
+                ButtonName: button.name,
+                Type: holdTierEventType(tier),
+                Tier: uint8(tier),
+            })
         }
-        // === Send event ===
-        eventType := EVT_NONE
-        if countRise > 1 {
-            eventType = EVT_MULTI
-        } else if countRise > 0 {
-            eventType = EVT_SINGLE
-        } else if detectLong {
-            eventType = EVT_LONG
-        } else if detectLongLong {
-            eventType = EVT_LONG_LONG
+    }
+    eventType := EVT_NONE
+    if countRise > 1 {
+        eventType = EVT_MULTI
+    } else if countRise > 0 {
+        eventType = EVT_SINGLE
+    }
+    if eventType != EVT_NONE {
+        buttons.sendButtonEvent(button, ButtonEvent {
+            ButtonName: button.name,
+            Type: eventType,
+            ClickCount: countRise,
+            RepeatCount: repeatCnt,
+        })
+    }
+}
+
+func (buttons *Buttons) sendEvent(event ButtonEvent) {
+    event.ScanCount = buttons.scanCnt
+    event.Timestamp = buttons.timestamp
+    buttons.enqueue(event)
+}
+
+// sendButtonEvent sends event of button, or holds it back while the button may be a member of a chord to complete.
+// If no room to hold back, the held events are sent first to keep the order and the button stops holding back until released
+func (buttons *Buttons) sendButtonEvent(button *Button, event ButtonEvent) {
+    if button.holdBack && int(button.heldCnt) == len(button.held) {
+        buttons.flushHeld(button)
+        button.holdBack = false
+        button.heldFull = true
+    }
+    if button.holdBack {
+        event.ScanCount = buttons.scanCnt
+        event.Timestamp = buttons.timestamp
+        button.held[button.heldCnt] = event
+        button.heldCnt++
+        return
+    }
+    buttons.sendEvent(event)
+}
+
+// flushHeld sends events held back by sendButtonEvent
+func (buttons *Buttons) flushHeld(button *Button) {
+    for i := uint8(0); i < button.heldCnt; i++ {
+        buttons.enqueue(button.held[i])
+    }
+    button.heldCnt = 0
+}
+
+// enqueue numbers event and pushes it to the event queue, or handles overflow
+func (buttons *Buttons) enqueue(event ButtonEvent) {
+    event.Sequence = buttons.sequence
+    buttons.sequence++
+    // coalesced events waiting for room go first to keep Sequence in order
+    if !buttons.hasCoalesced() && buttons.push(event) {
+        return
+    }
+    // === Overflow ===
+    switch OverflowPolicy(buttons.overflowPolicy.Load()) {
+    case OVERFLOW_DROP_OLDEST:
+        buttons.queue.dropOldest()
+        buttons.push(event)
+    case OVERFLOW_COALESCE:
+        if event.Type == EVT_SINGLE && event.RepeatCount > 0 {
+            if button := buttons.findButton(event.ButtonName); button != nil {
+                hadCoalesced := button.hasCoalesced
+                // overwrite older Repeat event, RepeatCount of the latest one tells how many repeats happened
+                button.coalesced = event
+                button.hasCoalesced = true
+                if !hadCoalesced {
+                    return
+                }
+                // the older one is dropped
+            }
         }
-        if eventType != EVT_NONE && len(buttons.event) < cap(buttons.event) {
-            buttons.event <- ButtonEvent {
-                ButtonName: button.name,
-                Type: eventType,
-                ClickCount: countRise,
-                RepeatCount: repeatCnt,
+    }
+    buttons.overflowCnt.Add(1)
+    buttons.overflowPending = true
+}
+
+func (buttons *Buttons) hasCoalesced() bool {
+    for _, button := range buttons.getButtons() {
+        if button.hasCoalesced {
+            return true
+        }
+    }
+    return false
+}
+
+// flushPending sends coalesced Repeat events and then EVT_OVERFLOW once the event queue has room
+func (buttons *Buttons) flushPending() {
+    for _, button := range buttons.getButtons() {
+        if button.hasCoalesced {
+            if !buttons.push(button.coalesced) {
+                return
             }
+            button.hasCoalesced = false
         }
     }
+    if buttons.overflowPending && buttons.hasRoom() {
+        buttons.overflowPending = false
+        buttons.push(ButtonEvent {
+            ButtonName: buttons.name,
+            Type: EVT_OVERFLOW,
+            ScanCount: buttons.scanCnt,
+            Timestamp: buttons.timestamp,
+            Sequence: buttons.sequence,
+        })
+        buttons.sequence++
+    }
+}
+
+func (buttons *Buttons) hasRoom() bool {
+    return buttons.queue.hasRoom()
+}
+
+func (buttons *Buttons) push(event ButtonEvent) bool {
+    return buttons.queue.push(event)
 }
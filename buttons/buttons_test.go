@@ -0,0 +1,376 @@
+package buttons
+
+import (
+    "testing"
+)
+
+type testPin struct {
+    level bool
+}
+
+func (pin *testPin) Get() bool {
+    return pin.level
+}
+
+// testEvent is the part of ButtonEvent compared by tests
+type testEvent struct {
+    name string
+    typ  ButtonEventType
+    scan uint32
+}
+
+func scanN(buttons *Buttons, n int) {
+    for i := 0; i < n; i++ {
+        ScanPeriodic(buttons)
+    }
+}
+
+func drainEvents(buttons *Buttons) []ButtonEvent {
+    var events []ButtonEvent
+    for event := buttons.GetEvent(); event != nil; event = buttons.GetEvent() {
+        events = append(events, *event)
+    }
+    return events
+}
+
+func toTestEvents(events []ButtonEvent) []testEvent {
+    var testEvents []testEvent
+    for _, event := range events {
+        testEvents = append(testEvents, testEvent{event.ButtonName, event.Type, event.ScanCount})
+    }
+    return testEvents
+}
+
+func drainTestEvents(buttons *Buttons) []testEvent {
+    return toTestEvents(drainEvents(buttons))
+}
+
+func checkEvents(t *testing.T, got, want []testEvent) {
+    t.Helper()
+    if len(got) != len(want) {
+        t.Fatalf("got %v, want %v", got, want)
+    }
+    for i := range got {
+        if got[i] != want[i] {
+            t.Fatalf("event %d: got %v, want %v", i, got[i], want[i])
+        }
+    }
+}
+
+// checkSequence checks Sequence of events is strictly increasing and its gaps match the overflow count
+func checkSequence(t *testing.T, buttons *Buttons, events []ButtonEvent) {
+    t.Helper()
+    var gaps uint32
+    overflow := false
+    for i, event := range events {
+        if event.Type == EVT_OVERFLOW {
+            overflow = true
+        }
+        if i == 0 {
+            gaps += event.Sequence
+            continue
+        }
+        if event.Sequence <= events[i - 1].Sequence {
+            t.Fatalf("Sequence %d after %d", event.Sequence, events[i - 1].Sequence)
+        }
+        gaps += event.Sequence - events[i - 1].Sequence - 1
+    }
+    if gaps != buttons.GetOverflowCount() {
+        t.Fatalf("Sequence gaps %d, overflow count %d", gaps, buttons.GetOverflowCount())
+    }
+    if gaps > 0 && !overflow {
+        t.Fatal("no EVT_OVERFLOW")
+    }
+}
+
+// lastRepeatCount returns RepeatCount of the last Repeat in scans, without overflow
+func lastRepeatCount(scans int) (count uint8) {
+    pin := &testPin{false}
+    buttons := New("test", NewButton("rpt", pin, DefaultButtonSingleRepeatConfig))
+    for i := 0; i < scans; i++ {
+        ScanPeriodic(buttons)
+        for _, event := range drainEvents(buttons) {
+            count = event.RepeatCount
+        }
+    }
+    return count
+}
+
+func TestOverflowPolicy(t *testing.T) {
+    for _, policy := range []OverflowPolicy{OVERFLOW_DROP_NEWEST, OVERFLOW_DROP_OLDEST, OVERFLOW_COALESCE} {
+        pin := &testPin{true}
+        buttons := New("test", NewButton("rpt", pin, DefaultButtonSingleRepeatConfig))
+        buttons.SetOverflowPolicy(policy)
+        // hold the repeat button against the full queue
+        pin.level = false
+        scanN(buttons, 200)
+        events := drainEvents(buttons)
+        pin.level = true
+        scanN(buttons, 2)
+        events = append(events, drainEvents(buttons)...)
+        if buttons.GetOverflowCount() == 0 {
+            t.Fatalf("policy %d: no overflow counted", policy)
+        }
+        checkSequence(t, buttons, events)
+        if policy == OVERFLOW_COALESCE {
+            // the latest Repeat is kept
+            var last ButtonEvent
+            for _, event := range events {
+                if event.Type == EVT_SINGLE {
+                    last = event
+                }
+            }
+            if last.RepeatCount != lastRepeatCount(200) {
+                t.Fatalf("latest RepeatCount %d, want %d", last.RepeatCount, lastRepeatCount(200))
+            }
+        }
+    }
+}
+
+func TestRepeatProfileStages(t *testing.T) {
+    pin := &testPin{true}
+    // Repeat every 3 scans, then every 2 scans after 6 scans, then every scan after 12 scans since Repeat started
+    config := DefaultButtonSingleRepeatConfig.WithRepeatProfile(RepeatStage{AfterCnt: 12, Skip: 0}, RepeatStage{AfterCnt: 6, Skip: 1})
+    buttons := New("test", NewButton("rpt", pin, config))
+    scanN(buttons, 1)
+    pin.level = false
+    scanN(buttons, 25) // scan 1 to 25
+    var got [][2]uint32
+    for _, event := range drainEvents(buttons) {
+        if event.RepeatCount > 0 {
+            got = append(got, [2]uint32{event.ScanCount, uint32(event.RepeatCount)})
+        }
+    }
+    want := [][2]uint32{{10, 1}, {13, 2}, {16, 3}, {18, 4}, {20, 5}, {22, 6}, {23, 7}, {24, 8}, {25, 9}}
+    if len(got) != len(want) {
+        t.Fatalf("Repeat (scan, count) %v, want %v", got, want)
+    }
+    for i := range got {
+        if got[i] != want[i] {
+            t.Fatalf("Repeat %d: (scan, count) %v, want %v", i, got[i], want[i])
+        }
+    }
+}
+
+func checkRepeatCounts(t *testing.T, events []ButtonEvent, want ...uint8) {
+    t.Helper()
+    var got []uint8
+    for _, event := range events {
+        if event.RepeatCount > 0 {
+            got = append(got, event.RepeatCount)
+        }
+    }
+    if len(got) != len(want) {
+        t.Fatalf("RepeatCount %v, want %v", got, want)
+    }
+    for i := range got {
+        if got[i] != want[i] {
+            t.Fatalf("RepeatCount %v, want %v", got, want)
+        }
+    }
+}
+
+func TestLongThenRepeat(t *testing.T) {
+    pin := &testPin{true}
+    // Long at 10 scans, then Repeat every 5 scans from 20 scans
+    buttons := New("test", NewButton("a", pin, NewButtonConfig(false, false, 1, 0, 20, 4, 10, 0)))
+    scanN(buttons, 1)
+    pin.level = false
+    scanN(buttons, 35) // scan 1 to 35
+    pin.level = true
+    scanN(buttons, 5)
+    events := drainEvents(buttons)
+    checkEvents(t, toTestEvents(events), []testEvent {
+        {"a", EVT_SINGLE, 1},
+        {"a", EVT_LONG, 10},
+        {"a", EVT_SINGLE, 20},
+        {"a", EVT_SINGLE, 25},
+        {"a", EVT_SINGLE, 30},
+        {"a", EVT_SINGLE, 35},
+        {"a", EVT_LONG_RELEASE, 36},
+    })
+    checkRepeatCounts(t, events, 1, 2, 3, 4)
+    if events[len(events) - 1].HoldCount != 35 {
+        t.Fatalf("HoldCount %d, want 35", events[len(events) - 1].HoldCount)
+    }
+}
+
+func TestLongAndRepeatAtSameScan(t *testing.T) {
+    pin := &testPin{true}
+    // Long and the first Repeat at 10 scans, LongLong at 30 scans among Repeat
+    buttons := New("test", NewButton("a", pin, NewButtonConfig(false, false, 1, 0, 10, 4, 10, 30)))
+    scanN(buttons, 1)
+    pin.level = false
+    scanN(buttons, 35) // scan 1 to 35
+    pin.level = true
+    scanN(buttons, 5)
+    events := drainEvents(buttons)
+    // hold tiers go before Repeat of the same scan
+    checkEvents(t, toTestEvents(events), []testEvent {
+        {"a", EVT_SINGLE, 1},
+        {"a", EVT_LONG, 10},
+        {"a", EVT_SINGLE, 10},
+        {"a", EVT_SINGLE, 15},
+        {"a", EVT_SINGLE, 20},
+        {"a", EVT_SINGLE, 25},
+        {"a", EVT_LONG_LONG, 30},
+        {"a", EVT_SINGLE, 30},
+        {"a", EVT_SINGLE, 35},
+        {"a", EVT_LONG_RELEASE, 36},
+    })
+    checkRepeatCounts(t, events, 1, 2, 3, 4, 5, 6)
+}
+
+// pushFor pushes pin for pushed scans, then releases it for released scans
+func pushFor(buttons *Buttons, pin *testPin, pushed, released int) {
+    pin.level = false
+    scanN(buttons, pushed)
+    pin.level = true
+    scanN(buttons, released)
+}
+
+func TestClickThenHold(t *testing.T) {
+    pin := &testPin{true}
+    buttons := New("test", NewButton("a", pin, DefaultButtonMultiConfig.WithClickHold(true)))
+    scanN(buttons, 1)
+    pushFor(buttons, pin, 2, 2)   // scan 1 to 4: click
+    pushFor(buttons, pin, 50, 10) // scan 5 to 64: hold over LongLong (39 scans)
+    pushFor(buttons, pin, 2, 2)   // scan 65 to 68: click
+    pushFor(buttons, pin, 2, 2)   // scan 69 to 72: click
+    pushFor(buttons, pin, 50, 10) // scan 73 to 132: hold
+    pushFor(buttons, pin, 50, 10) // scan 133 to 192: hold without click
+    events := drainEvents(buttons)
+    checkEvents(t, toTestEvents(events), []testEvent {
+        {"a", EVT_CLICK_HOLD, 19},
+        {"a", EVT_LONG_RELEASE, 55},
+        {"a", EVT_CLICK_HOLD, 87},
+        {"a", EVT_LONG_RELEASE, 123},
+        {"a", EVT_LONG, 147},
+        {"a", EVT_LONG_LONG, 171},
+        {"a", EVT_LONG_RELEASE, 183},
+    })
+    if events[0].ClickCount != 1 || events[2].ClickCount != 2 {
+        t.Fatalf("ClickCount %d and %d, want 1 and 2", events[0].ClickCount, events[2].ClickCount)
+    }
+    if events[1].HoldCount != 50 || events[3].HoldCount != 50 {
+        t.Fatalf("HoldCount %d and %d, want 50", events[1].HoldCount, events[3].HoldCount)
+    }
+}
+
+func TestSetConfigWhilePushed(t *testing.T) {
+    pin := &testPin{true}
+    buttons := New("test", NewButton("a", pin, DefaultButtonSingleRepeatConfig))
+    scanN(buttons, 1)
+    pin.level = false
+    scanN(buttons, 5) // scan 1 to 5
+    checkEvents(t, drainTestEvents(buttons), []testEvent {
+        {"a", EVT_SINGLE, 1},
+    })
+    if err := buttons.SetConfig("a", DefaultButtonMultiConfig); err != nil {
+        t.Fatal(err)
+    }
+    scanN(buttons, 50) // scan 6 to 55: neither Repeat by the old config nor Long by the new one
+    pin.level = true
+    scanN(buttons, 10) // scan 56 to 65: nor click at release
+    checkEvents(t, drainTestEvents(buttons), nil)
+    pushFor(buttons, pin, 2, 10) // scan 66 to 77
+    checkEvents(t, drainTestEvents(buttons), []testEvent {
+        {"a", EVT_SINGLE, 72},
+    })
+}
+
+func TestSetConfigDiscardsHistory(t *testing.T) {
+    pin := &testPin{true}
+    buttons := New("test", NewButton("a", pin, DefaultButtonMultiConfig))
+    scanN(buttons, 1)
+    pushFor(buttons, pin, 2, 1) // scan 1 to 3: click waiting to be determined
+    if err := buttons.SetConfig("a", DefaultButtonMultiConfig.WithClickHold(true)); err != nil {
+        t.Fatal(err)
+    }
+    scanN(buttons, 20)
+    checkEvents(t, drainTestEvents(buttons), nil)
+    pushFor(buttons, pin, 2, 10) // scan 24 to 35
+    checkEvents(t, drainTestEvents(buttons), []testEvent {
+        {"a", EVT_SINGLE, 30},
+    })
+    if err := buttons.SetConfig("b", DefaultButtonMultiConfig); err == nil {
+        t.Fatal("no error for unknown button")
+    }
+}
+
+func TestAddRemoveButton(t *testing.T) {
+    a, b, c := &testPin{true}, &testPin{true}, &testPin{true}
+    buttons := New("test", NewButton("a", a, DefaultButtonSingleConfig), NewButton("c", c, DefaultButtonSingleConfig))
+    if err := buttons.AddChord(NewChord("a+c", 0, CHORD_RELEASE_ALL, "a", "c")); err != nil {
+        t.Fatal(err)
+    }
+    scanN(buttons, 1)
+    if err := buttons.AddButton(NewButton("a", b, DefaultButtonSingleConfig)); err == nil {
+        t.Fatal("no error for duplicated name")
+    }
+    if err := buttons.AddButton(NewButton("b", b, DefaultButtonSingleConfig)); err != nil {
+        t.Fatal(err)
+    }
+    pushFor(buttons, b, 2, 2) // scan 1 to 4
+    checkEvents(t, drainTestEvents(buttons), []testEvent {
+        {"b", EVT_SINGLE, 1},
+    })
+    if err := buttons.RemoveButton("a"); err == nil {
+        t.Fatal("no error for removing chord member")
+    }
+    if err := buttons.RemoveButton("b"); err != nil {
+        t.Fatal(err)
+    }
+    if err := buttons.RemoveButton("b"); err == nil {
+        t.Fatal("no error for removing unknown button")
+    }
+    pushFor(buttons, b, 2, 2)
+    pushFor(buttons, a, 2, 2) // scan 9 to 12
+    checkEvents(t, drainTestEvents(buttons), []testEvent {
+        {"a", EVT_SINGLE, 9},
+    })
+    if err := buttons.RemoveChord("a+c"); err != nil {
+        t.Fatal(err)
+    }
+    if err := buttons.RemoveButton("a"); err != nil {
+        t.Fatal(err)
+    }
+}
+
+func TestDisableButton(t *testing.T) {
+    a, c := &testPin{true}, &testPin{true}
+    buttons := New("test", NewButton("a", a, DefaultButtonSingleConfig), NewButton("c", c, DefaultButtonSingleConfig))
+    if err := buttons.AddChord(NewChord("a+c", 0, CHORD_RELEASE_ALL, "a", "c")); err != nil {
+        t.Fatal(err)
+    }
+    scanN(buttons, 1)
+    if err := buttons.DisableButton("a"); err != nil {
+        t.Fatal(err)
+    }
+    pushFor(buttons, a, 2, 2) // scan 1 to 4: masked
+    // chord including the disabled button doesn't complete
+    a.level, c.level = false, false
+    scanN(buttons, 3) // scan 5 to 7
+    a.level, c.level = true, true
+    scanN(buttons, 10) // scan 8 to 17
+    checkEvents(t, drainTestEvents(buttons), []testEvent {
+        {"c", EVT_SINGLE, 5},
+    })
+    // enabled while pushed: silent until released
+    a.level = false
+    scanN(buttons, 2) // scan 18 to 19
+    if err := buttons.EnableButton("a"); err != nil {
+        t.Fatal(err)
+    }
+    scanN(buttons, 2)
+    a.level = true
+    scanN(buttons, 2) // scan 22 to 23
+    checkEvents(t, drainTestEvents(buttons), nil)
+    pushFor(buttons, a, 2, 2) // scan 24 to 27
+    checkEvents(t, drainTestEvents(buttons), []testEvent {
+        {"a", EVT_SINGLE, 24},
+    })
+    if err := buttons.DisableButton("b"); err == nil {
+        t.Fatal("no error for unknown button")
+    }
+}
@@ -0,0 +1,176 @@
+package buttons
+
+import (
+    "fmt"
+)
+
+type ChordRelease int
+const (
+    CHORD_RELEASE_ALL ChordRelease = iota // chord finishes when all member buttons are released
+    CHORD_RELEASE_ANY                      // chord finishes when any member button is released
+)
+
+// Chord detects member buttons pushed together as one EVT_CHORD event, and EVT_CHORD_RELEASE when it finishes after EVT_CHORD.
+// While some of the members are pushed, their events are held back for graceCnt scans from the first push waiting
+// for the others. If the chord completes, the held events are discarded and the members don't emit their own events
+// until each of them is released. Otherwise the held events are sent late (with their original ScanCount and Timestamp).
+// Note that a member button pushed earlier than graceCnt scans before the others, or emitting more than chordHeldMax events
+// within graceCnt (e.g. Repeat), may have emitted its own events already
+type Chord struct {
+    name        string
+    buttonNames []string
+    holdCnt     uint16       // continuous counts of all members pushed to detect chord (detect immediately if 0 or 1)
+    release     ChordRelease
+    graceCnt    uint8        // scans to hold back events of members while the chord is partially pushed
+    members     []*Button
+    active      bool
+    cnt         uint16
+    fired       bool
+    activeCnt   uint16       // scans since the chord started
+    partialCnt  uint8        // scans since the chord is partially pushed
+}
+
+const chordDefaultGraceCnt = 4
+const chordHeldMax = 4 // events held back per button
+
+func NewChord(name string, holdCnt uint8, release ChordRelease, buttonName ...string) *Chord {
+    return &Chord {
+        name: name,
+        buttonNames: append([]string{}, buttonName...),
+        holdCnt: uint16(holdCnt),
+        release: release,
+        graceCnt: chordDefaultGraceCnt,
+    }
+}
+
+// SetGraceCnt sets scans to hold back events of members while the chord is partially pushed (0 not to hold back).
+// Call it before AddChord()
+func (chord *Chord) SetGraceCnt(graceCnt uint8) {
+    chord.graceCnt = graceCnt
+}
+
+func (chord *Chord) GetName() string {
+    return chord.name
+}
+
+// AddChord registers chord on buttons, it is safe while ScanPeriodic is running
+func (buttons *Buttons) AddChord(chord *Chord) error {
+    buttons.mutex.Lock()
+    defer buttons.mutex.Unlock()
+    if len(chord.buttonNames) < 2 {
+        return fmt.Errorf("chord %s needs at least 2 buttons", chord.name)
+    }
+    members := []*Button{}
+    for _, buttonName := range chord.buttonNames {
+        button := buttons.findButton(buttonName)
+        if button == nil {
+            return fmt.Errorf("chord %s: button %s not found", chord.name, buttonName)
+        }
+        members = append(members, button)
+    }
+    chord.members = members
+    chords := append(append([]*Chord{}, buttons.getChords()...), chord)
+    buttons.chords.Store(&chords)
+    return nil
+}
+
+// RemoveChord unregisters the chord of name, it is safe while ScanPeriodic is running
+func (buttons *Buttons) RemoveChord(name string) error {
+    buttons.mutex.Lock()
+    defer buttons.mutex.Unlock()
+    chords := []*Chord{}
+    for _, chord := range buttons.getChords() {
+        if chord.name != name {
+            chords = append(chords, chord)
+        }
+    }
+    if len(chords) == len(buttons.getChords()) {
+        return fmt.Errorf("chord %s not found", name)
+    }
+    buttons.chords.Store(&chords)
+    return nil
+}
+
+func (buttons *Buttons) scanChords() {
+    buttonSlice := buttons.getButtons()
+    for _, button := range buttonSlice {
+        button.holdBack = false
+        if !button.pressed {
+            button.heldFull = false
+        }
+    }
+    for _, chord := range buttons.getChords() {
+        allPressed := true
+        anyPressed := false
+        for _, button := range chord.members {
+            // disabled member is regarded as released
+            pressed := button.pressed && !button.disabled.Load()
+            allPressed = allPressed && pressed
+            anyPressed = anyPressed || pressed
+        }
+        // === Check chord start/finish ===
+        if !chord.active {
+            if !allPressed {
+                // hold back events of pushed members while the others may follow
+                if !anyPressed {
+                    chord.partialCnt = 0
+                } else if chord.partialCnt < chord.graceCnt {
+                    chord.partialCnt++
+                    for _, button := range chord.members {
+                        if button.pressed && !button.heldFull {
+                            button.holdBack = true
+                        }
+                    }
+                }
+                continue
+            }
+            chord.active = true
+            chord.cnt = 0
+            chord.activeCnt = 0
+            chord.fired = false
+            chord.partialCnt = 0
+            // events held back belong to the chord
+            for _, button := range chord.members {
+                button.heldCnt = 0
+            }
+        } else if (chord.release == CHORD_RELEASE_ALL && !anyPressed) || (chord.release == CHORD_RELEASE_ANY && !allPressed) {
+            chord.active = false
+            if chord.fired {
+                buttons.sendEvent(ButtonEvent {
+                    ButtonName: chord.name,
+                    Type: EVT_CHORD_RELEASE,
+                    HoldCount: chord.activeCnt,
+                })
+            }
+            continue
+        }
+        if chord.activeCnt < 65535 {
+            chord.activeCnt++
+        }
+        // keep members muted while chord in progress
+        for _, button := range chord.members {
+            button.muted = true
+        }
+        // === Detect chord (all members continuously pushed for holdCnt) ===
+        if !allPressed {
+            chord.cnt = 0
+        } else if !chord.fired {
+            if chord.cnt < 65535 {
+                chord.cnt++
+            }
+            if chord.cnt >= chord.holdCnt {
+                chord.fired = true
+                buttons.sendEvent(ButtonEvent {
+                    ButtonName: chord.name,
+                    Type: EVT_CHORD,
+                })
+            }
+        }
+    }
+    // === Send events held back for the chords not completed ===
+    for _, button := range buttonSlice {
+        if !button.holdBack && button.heldCnt > 0 {
+            buttons.flushHeld(button)
+        }
+    }
+}
@@ -0,0 +1,99 @@
+package buttons
+
+import (
+    "testing"
+)
+
+func newChordButtons(t *testing.T, holdCnt uint8) (*Buttons, *testPin, *testPin) {
+    a, c := &testPin{true}, &testPin{true}
+    buttons := New("test",
+        NewButton("a", a, DefaultButtonSingleConfig),
+        NewButton("c", c, DefaultButtonSingleConfig),
+    )
+    if err := buttons.AddChord(NewChord("a+c", holdCnt, CHORD_RELEASE_ALL, "a", "c")); err != nil {
+        t.Fatal(err)
+    }
+    return buttons, a, c
+}
+
+func TestChordStaggeredPush(t *testing.T) {
+    buttons, a, c := newChordButtons(t, 3)
+    scanN(buttons, 2)
+    a.level = false
+    scanN(buttons, 1) // scan 2: a alone, its EVT_SINGLE is held back
+    c.level = false
+    scanN(buttons, 10) // scan 3 to 12: chord fires at the 3rd scan with all pushed
+    a.level, c.level = true, true
+    scanN(buttons, 3)
+    checkEvents(t, drainTestEvents(buttons), []testEvent {
+        {"a+c", EVT_CHORD, 5},
+        {"a+c", EVT_CHORD_RELEASE, 13},
+    })
+}
+
+func TestChordReleaseHoldCount(t *testing.T) {
+    buttons, a, c := newChordButtons(t, 0)
+    a.level, c.level = false, false
+    scanN(buttons, 10)
+    a.level, c.level = true, true
+    scanN(buttons, 1)
+    events := drainEvents(buttons)
+    if len(events) != 2 || events[1].Type != EVT_CHORD_RELEASE || events[1].HoldCount != 10 {
+        t.Fatalf("unexpected events %v", events)
+    }
+}
+
+func TestChordGraceTap(t *testing.T) {
+    buttons, a, _ := newChordButtons(t, 3)
+    scanN(buttons, 2)
+    a.level = false
+    scanN(buttons, 2)
+    checkEvents(t, drainTestEvents(buttons), nil)
+    a.level = true
+    scanN(buttons, 1) // released before the grace expires: held event is sent with its original scan count
+    checkEvents(t, drainTestEvents(buttons), []testEvent {
+        {"a", EVT_SINGLE, 2},
+    })
+}
+
+func TestChordGraceExpired(t *testing.T) {
+    buttons, a, _ := newChordButtons(t, 3)
+    scanN(buttons, 2)
+    a.level = false
+    scanN(buttons, int(chordDefaultGraceCnt))
+    checkEvents(t, drainTestEvents(buttons), nil)
+    scanN(buttons, 1)
+    checkEvents(t, drainTestEvents(buttons), []testEvent {
+        {"a", EVT_SINGLE, 2},
+    })
+}
+
+func TestChordGraceHeldFull(t *testing.T) {
+    a, c := &testPin{true}, &testPin{true}
+    buttons := New("test",
+        NewButton("a", a, DefaultButtonSingleRepeatConfig.WithPressRelease(true)),
+        NewButton("c", c, DefaultButtonSingleConfig),
+    )
+    chord := NewChord("a+c", 3, CHORD_RELEASE_ALL, "a", "c")
+    chord.SetGraceCnt(40)
+    if err := buttons.AddChord(chord); err != nil {
+        t.Fatal(err)
+    }
+    scanN(buttons, 1)
+    a.level = false
+    scanN(buttons, 30) // scan 1 to 30: a alone with Repeat, more events than held back
+    a.level = true
+    scanN(buttons, 2)
+    checkEvents(t, drainTestEvents(buttons), []testEvent {
+        {"a", EVT_PRESS, 1},
+        {"a", EVT_SINGLE, 1},
+        {"a", EVT_SINGLE, 10},
+        {"a", EVT_SINGLE, 13},
+        {"a", EVT_SINGLE, 16},
+        {"a", EVT_SINGLE, 19},
+        {"a", EVT_SINGLE, 22},
+        {"a", EVT_SINGLE, 25},
+        {"a", EVT_SINGLE, 28},
+        {"a", EVT_RELEASE, 31},
+    })
+}
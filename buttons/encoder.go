@@ -0,0 +1,164 @@
+package buttons
+
+import (
+    "fmt"
+)
+
+// Encoder decodes a quadrature rotary encoder sampled by ScanPeriodic and sends EVT_ROTATE with Delta in detents.
+// Transitions changing both A and B at once are rejected as invalid (counted by GetInvalidCount()).
+// The scan period needs to be short enough (e.g. 1 ms) not to miss transitions of quick rotation.
+// A push button of the encoder is handled as an ordinary Button
+type Encoder struct {
+    name           string
+    pinA           Pin
+    pinB           Pin
+    stepsPerDetent int8   // transitions per detent (4 for encoders with a full cycle per detent)
+    accelCnt       uint16 // detents within accelCnt scans from the previous one in the same direction accelerate (no acceleration if 0)
+    accelMax       int16  // maximum multiplier of acceleration
+    state          uint8  // last levels of A (bit 1) and B (bit 0)
+    initialized    bool
+    sub            int8   // transitions accumulated toward the next detent
+    lastDir        int8
+    idleCnt        uint16 // scans since the last detent
+    multiplier     int16
+    invalidCnt     uint32
+}
+
+// quadrature direction by last state (bit 3:2) and current state (bit 1:0), valid transitions only
+var quadratureTable = [16]int8{0, -1, 1, 0, 1, 0, 0, -1, -1, 0, 0, 1, 0, 1, -1, 0}
+
+func NewEncoder(name string, pinA, pinB Pin, stepsPerDetent uint8) *Encoder {
+    if stepsPerDetent == 0 {
+        stepsPerDetent = 1
+    }
+    return &Encoder {
+        name: name,
+        pinA: pinA,
+        pinB: pinB,
+        stepsPerDetent: int8(stepsPerDetent),
+        accelMax: 1,
+        multiplier: 1,
+    }
+}
+
+// SetAcceleration makes Delta multiplied by 2, 3, ... up to accelMax while detents continue within accelCnt scans.
+// Call it before AddEncoder()
+func (encoder *Encoder) SetAcceleration(accelCnt uint16, accelMax uint8) {
+    encoder.accelCnt = accelCnt
+    encoder.accelMax = int16(accelMax)
+    if encoder.accelMax < 1 {
+        encoder.accelMax = 1
+    }
+}
+
+func (encoder *Encoder) GetName() string {
+    return encoder.name
+}
+
+func (encoder *Encoder) GetInvalidCount() uint32 {
+    return encoder.invalidCnt
+}
+
+// AddEncoder registers encoder on buttons, it is safe while ScanPeriodic is running
+func (buttons *Buttons) AddEncoder(encoder *Encoder) error {
+    buttons.mutex.Lock()
+    defer buttons.mutex.Unlock()
+    for _, e := range buttons.getEncoders() {
+        if e.name == encoder.name {
+            return fmt.Errorf("encoder %s already exists", encoder.name)
+        }
+    }
+    encoders := append(append([]*Encoder{}, buttons.getEncoders()...), encoder)
+    buttons.encoders.Store(&encoders)
+    return nil
+}
+
+// RemoveEncoder unregisters the encoder of name, it is safe while ScanPeriodic is running
+func (buttons *Buttons) RemoveEncoder(name string) error {
+    buttons.mutex.Lock()
+    defer buttons.mutex.Unlock()
+    encoders := []*Encoder{}
+    for _, encoder := range buttons.getEncoders() {
+        if encoder.name != name {
+            encoders = append(encoders, encoder)
+        }
+    }
+    if len(encoders) == len(buttons.getEncoders()) {
+        return fmt.Errorf("encoder %s not found", name)
+    }
+    buttons.encoders.Store(&encoders)
+    return nil
+}
+
+func (buttons *Buttons) getEncoders() []*Encoder {
+    return *buttons.encoders.Load()
+}
+
+func (buttons *Buttons) scanEncoders() {
+    for _, encoder := range buttons.getEncoders() {
+        if delta := encoder.scan(); delta != 0 {
+            buttons.sendEvent(ButtonEvent {
+                ButtonName: encoder.name,
+                Type: EVT_ROTATE,
+                Delta: delta,
+            })
+        }
+    }
+}
+
+// moved tells the levels of A and B have changed since the last scan
+func (encoder *Encoder) moved() bool {
+    return encoder.pinA.Get() != (encoder.state & 0b10 != 0) || encoder.pinB.Get() != (encoder.state & 0b01 != 0)
+}
+
+// scan returns detents rotated (with acceleration) since the last scan
+func (encoder *Encoder) scan() int16 {
+    var state uint8
+    if encoder.pinA.Get() {
+        state |= 0b10
+    }
+    if encoder.pinB.Get() {
+        state |= 0b01
+    }
+    if encoder.idleCnt < 65535 {
+        encoder.idleCnt++
+    }
+    if !encoder.initialized {
+        encoder.initialized = true
+        encoder.state = state
+        return 0
+    }
+    lastState := encoder.state
+    encoder.state = state
+    // === Decode transition ===
+    switch lastState ^ state {
+    case 0b00:
+        return 0
+    case 0b11:
+        // both changed: direction unknown, lose the transitions accumulated
+        encoder.invalidCnt++
+        encoder.sub = 0
+        return 0
+    }
+    encoder.sub += quadratureTable[lastState << 2 | state]
+    var dir int8
+    if encoder.sub >= encoder.stepsPerDetent {
+        dir = 1
+    } else if encoder.sub <= -encoder.stepsPerDetent {
+        dir = -1
+    } else {
+        return 0
+    }
+    encoder.sub -= dir * encoder.stepsPerDetent
+    // === Accelerate ===
+    if dir == encoder.lastDir && encoder.idleCnt <= encoder.accelCnt {
+        if encoder.multiplier < encoder.accelMax {
+            encoder.multiplier++
+        }
+    } else {
+        encoder.multiplier = 1
+    }
+    encoder.lastDir = dir
+    encoder.idleCnt = 0
+    return int16(dir) * encoder.multiplier
+}
@@ -0,0 +1,139 @@
+package buttons
+
+import (
+    "testing"
+)
+
+// testEncoder drives A and B of an encoder, starting at both low
+type testEncoder struct {
+    a, b    *testPin
+    buttons *Buttons
+    encoder *Encoder
+}
+
+// positive (A leading B) cycle of A (bit 1) and B (bit 0)
+var testCycle = []uint8{0b00, 0b10, 0b11, 0b01}
+
+func newTestEncoder(t *testing.T, stepsPerDetent uint8) *testEncoder {
+    enc := &testEncoder{a: &testPin{false}, b: &testPin{false}}
+    enc.encoder = NewEncoder("enc", enc.a, enc.b, stepsPerDetent)
+    enc.buttons = New("test")
+    if err := enc.buttons.AddEncoder(enc.encoder); err != nil {
+        t.Fatal(err)
+    }
+    scanN(enc.buttons, 1)
+    return enc
+}
+
+func (enc *testEncoder) set(state uint8) {
+    enc.a.level = state & 0b10 != 0
+    enc.b.level = state & 0b01 != 0
+    scanN(enc.buttons, 1)
+}
+
+// turn makes transitions one per scan, positive for A leading B
+func (enc *testEncoder) turn(transitions int) {
+    pos := 0
+    for i, state := range testCycle {
+        if state == enc.encoder.state {
+            pos = i
+        }
+    }
+    for ; transitions > 0; transitions-- {
+        pos = (pos + 1) % len(testCycle)
+        enc.set(testCycle[pos])
+    }
+    for ; transitions < 0; transitions++ {
+        pos = (pos + len(testCycle) - 1) % len(testCycle)
+        enc.set(testCycle[pos])
+    }
+}
+
+func (enc *testEncoder) deltas() []int16 {
+    var deltas []int16
+    for _, event := range drainEvents(enc.buttons) {
+        if event.Type != EVT_ROTATE || event.ButtonName != "enc" {
+            continue
+        }
+        deltas = append(deltas, event.Delta)
+    }
+    return deltas
+}
+
+func checkDeltas(t *testing.T, got []int16, want ...int16) {
+    t.Helper()
+    if len(got) != len(want) {
+        t.Fatalf("deltas %v, want %v", got, want)
+    }
+    for i := range got {
+        if got[i] != want[i] {
+            t.Fatalf("deltas %v, want %v", got, want)
+        }
+    }
+}
+
+func TestEncoderDirection(t *testing.T) {
+    enc := newTestEncoder(t, 4)
+    enc.turn(4)
+    checkDeltas(t, enc.deltas(), 1)
+    enc.turn(-4)
+    checkDeltas(t, enc.deltas(), -1)
+    enc.turn(-8)
+    checkDeltas(t, enc.deltas(), -1, -1)
+    if enc.encoder.GetInvalidCount() != 0 {
+        t.Fatalf("%d invalid transitions", enc.encoder.GetInvalidCount())
+    }
+}
+
+func TestEncoderStepsPerDetent(t *testing.T) {
+    enc := newTestEncoder(t, 2)
+    enc.turn(1)
+    checkDeltas(t, enc.deltas())
+    enc.turn(1)
+    checkDeltas(t, enc.deltas(), 1)
+    // back and forth between detents doesn't rotate
+    enc.turn(1)
+    enc.turn(-1)
+    enc.turn(1)
+    checkDeltas(t, enc.deltas())
+    enc.turn(1)
+    checkDeltas(t, enc.deltas(), 1)
+}
+
+func TestEncoderInvalid(t *testing.T) {
+    enc := newTestEncoder(t, 4)
+    enc.turn(3)
+    enc.set(0b10) // 01 to 10: both changed, the accumulated transitions are lost
+    if enc.encoder.GetInvalidCount() != 1 || enc.encoder.sub != 0 {
+        t.Fatalf("%d invalid transitions, sub %d", enc.encoder.GetInvalidCount(), enc.encoder.sub)
+    }
+    enc.turn(3) // 10 to 00 is 3 transitions: not a detent yet
+    checkDeltas(t, enc.deltas())
+    enc.turn(1)
+    checkDeltas(t, enc.deltas(), 1)
+}
+
+func TestEncoderAcceleration(t *testing.T) {
+    enc := newTestEncoder(t, 1)
+    enc.encoder.SetAcceleration(3, 3)
+    enc.turn(5)
+    checkDeltas(t, enc.deltas(), 1, 2, 3, 3, 3)
+    // slower than accelCnt resets the multiplier
+    scanN(enc.buttons, 3)
+    enc.turn(2)
+    checkDeltas(t, enc.deltas(), 1, 2)
+    // reverse resets the multiplier
+    enc.turn(-2)
+    checkDeltas(t, enc.deltas(), -1, -2)
+}
+
+func TestEncoderIdleBetweenDetents(t *testing.T) {
+    enc := newTestEncoder(t, 4)
+    suspended := false
+    enc.buttons.SetIdleSuspend(5, func() { suspended = true }, func() error { return nil })
+    enc.turn(2) // resting between detents
+    scanN(enc.buttons, 5)
+    if !suspended || enc.encoder.sub == 0 {
+        t.Fatalf("suspended %v, sub %d", suspended, enc.encoder.sub)
+    }
+}
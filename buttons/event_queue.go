@@ -0,0 +1,62 @@
+package buttons
+
+import (
+    "sync/atomic"
+)
+
+// eventQueue is a fixed-size ring buffer of ButtonEvent without allocation,
+// for a single producer (ScanPeriodic, typically in timer interrupt) and a single consumer (main loop).
+// Indexes are free running, thus ButtonEventChanSize must be a power of 2.
+// The producer may also drop the oldest event (OVERFLOW_DROP_OLDEST), which is arbitrated with the consumer by CompareAndSwap of head
+type eventQueue struct {
+    buf  [ButtonEventChanSize]ButtonEvent
+    head atomic.Uint32 // index to read next, advanced by consumer (or by producer to drop the oldest)
+    tail atomic.Uint32 // index to write next, advanced by producer only
+}
+
+func (queue *eventQueue) len() int {
+    return int(queue.tail.Load() - queue.head.Load())
+}
+
+func (queue *eventQueue) hasRoom() bool {
+    return queue.len() < ButtonEventChanSize
+}
+
+// push is called by producer only
+func (queue *eventQueue) push(event ButtonEvent) bool {
+    tail := queue.tail.Load()
+    if tail - queue.head.Load() >= ButtonEventChanSize {
+        return false
+    }
+    queue.buf[tail % ButtonEventChanSize] = event
+    queue.tail.Store(tail + 1)
+    return true
+}
+
+// pop is called by consumer only
+func (queue *eventQueue) pop(event *ButtonEvent) bool {
+    for {
+        head := queue.head.Load()
+        if head == queue.tail.Load() {
+            return false
+        }
+        *event = queue.buf[head % ButtonEventChanSize]
+        if queue.head.CompareAndSwap(head, head + 1) {
+            return true
+        }
+        // producer dropped the oldest (and might overwrite it) while reading, retry
+    }
+}
+
+// dropOldest is called by producer only
+func (queue *eventQueue) dropOldest() bool {
+    for {
+        head := queue.head.Load()
+        if head == queue.tail.Load() {
+            return false
+        }
+        if queue.head.CompareAndSwap(head, head + 1) {
+            return true
+        }
+    }
+}
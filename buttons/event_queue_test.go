@@ -0,0 +1,119 @@
+package buttons
+
+import (
+    "math"
+    "sync"
+    "testing"
+)
+
+func TestEventQueueWrapAround(t *testing.T) {
+    var queue eventQueue
+    // free running indexes wrap around uint32 on the way
+    queue.head.Store(math.MaxUint32 - 40)
+    queue.tail.Store(math.MaxUint32 - 40)
+    var next, want uint32
+    for round := 0; round < 10; round++ {
+        for queue.push(ButtonEvent{Sequence: next}) {
+            next++
+        }
+        if queue.len() != ButtonEventChanSize {
+            t.Fatalf("round %d: len %d when full", round, queue.len())
+        }
+        // pop a part to shift the position in the ring
+        for i := 0; i < ButtonEventChanSize / 2 + round; i++ {
+            var event ButtonEvent
+            if !queue.pop(&event) {
+                break
+            }
+            if event.Sequence != want {
+                t.Fatalf("round %d: popped %d, want %d", round, event.Sequence, want)
+            }
+            want++
+        }
+    }
+    var event ButtonEvent
+    for queue.pop(&event) {
+        if event.Sequence != want {
+            t.Fatalf("popped %d, want %d", event.Sequence, want)
+        }
+        want++
+    }
+    if want != next || queue.len() != 0 {
+        t.Fatalf("popped up to %d of %d, len %d", want, next, queue.len())
+    }
+}
+
+func TestEventQueueDropOldest(t *testing.T) {
+    var queue eventQueue
+    for i := uint32(0); i < ButtonEventChanSize; i++ {
+        queue.push(ButtonEvent{Sequence: i})
+    }
+    var event ButtonEvent
+    // dropOldest and pop interleaved: each takes the oldest once
+    for i := uint32(0); i < ButtonEventChanSize; i += 2 {
+        if !queue.dropOldest() {
+            t.Fatalf("no event to drop at %d", i)
+        }
+        if !queue.pop(&event) || event.Sequence != i + 1 {
+            t.Fatalf("popped %d, want %d", event.Sequence, i + 1)
+        }
+    }
+    if queue.dropOldest() || queue.pop(&event) {
+        t.Fatal("dropped or popped from empty queue")
+    }
+}
+
+func TestEventQueueConcurrentDropOldest(t *testing.T) {
+    const total = 100000
+    var queue eventQueue
+    var wg sync.WaitGroup
+    wg.Add(1)
+    go func() {
+        defer wg.Done()
+        for i := uint32(1); i <= total; i++ {
+            if !queue.push(ButtonEvent{Sequence: i}) {
+                queue.dropOldest()
+                queue.push(ButtonEvent{Sequence: i})
+            }
+        }
+    }()
+    var last uint32
+    for last < total {
+        var event ButtonEvent
+        if !queue.pop(&event) {
+            continue
+        }
+        if event.Sequence <= last {
+            t.Fatalf("popped %d after %d", event.Sequence, last)
+        }
+        last = event.Sequence
+    }
+    wg.Wait()
+}
+
+func TestDrainEventsShortDst(t *testing.T) {
+    buttons := New("test")
+    for i := uint32(0); i < 10; i++ {
+        buttons.queue.push(ButtonEvent{Sequence: i})
+    }
+    dst := make([]ButtonEvent, 3)
+    var want uint32
+    for want < 10 {
+        count := buttons.DrainEvents(dst)
+        if expected := min(3, 10 - int(want)); count != expected {
+            t.Fatalf("drained %d, want %d", count, expected)
+        }
+        for _, event := range dst[:count] {
+            if event.Sequence != want {
+                t.Fatalf("drained %d, want %d", event.Sequence, want)
+            }
+            want++
+        }
+    }
+    if count := buttons.DrainEvents(dst); count != 0 {
+        t.Fatalf("drained %d from empty queue", count)
+    }
+    if count := buttons.DrainEvents(nil); count != 0 {
+        t.Fatalf("drained %d to nil", count)
+    }
+}
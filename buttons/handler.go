@@ -0,0 +1,70 @@
+package buttons
+
+type Handler func(event *ButtonEvent)
+
+type HandlerId uint32
+
+type handlerEntry struct {
+    id         HandlerId
+    buttonName string
+    eventType  ButtonEventType
+    handler    Handler
+}
+
+// On registers handler for events of buttonName ("" for any button) and eventType (EVT_NONE for any type).
+// Handlers are called by Dispatch() in the order of registration
+func (buttons *Buttons) On(buttonName string, eventType ButtonEventType, handler Handler) HandlerId {
+    buttons.handlerId++
+    entry := handlerEntry {
+        id: buttons.handlerId,
+        buttonName: buttonName,
+        eventType: eventType,
+        handler: handler,
+    }
+    // copy on write not to disturb Dispatch() in progress (when called from handler)
+    buttons.handlers = append(append([]handlerEntry{}, buttons.handlers...), entry)
+    return entry.id
+}
+
+// Off unregisters handler registered by On(), returns false if not found
+func (buttons *Buttons) Off(id HandlerId) bool {
+    for i, entry := range buttons.handlers {
+        if entry.id == id {
+            handlers := append([]handlerEntry{}, buttons.handlers[:i]...)
+            buttons.handlers = append(handlers, buttons.handlers[i + 1:]...)
+            return true
+        }
+    }
+    return false
+}
+
+// Dispatch gets all queued events and calls matching handlers, returns the number of events got.
+// Events are also fed to the matchers given to AddSequenceMatcher(), and EVT_SEQUENCE is dispatched after the event completing it.
+// Call it from main loop instead of GetEvent(), not from interrupt
+func (buttons *Buttons) Dispatch() (count int) {
+    for event := buttons.GetEvent(); event != nil; event = buttons.GetEvent() {
+        buttons.dispatch(event)
+        for _, matcher := range buttons.matchers {
+            if seqEvent := matcher.Feed(event); seqEvent != nil {
+                buttons.dispatch(seqEvent)
+            }
+        }
+        count++
+    }
+    if len(buttons.matchers) > 0 {
+        now := buttons.clock()
+        for _, matcher := range buttons.matchers {
+            matcher.Tick(now)
+        }
+    }
+    return count
+}
+
+func (buttons *Buttons) dispatch(event *ButtonEvent) {
+    for _, entry := range buttons.handlers {
+        if (entry.buttonName == "" || entry.buttonName == event.ButtonName) &&
+           (entry.eventType == EVT_NONE || entry.eventType == event.Type) {
+            entry.handler(event)
+        }
+    }
+}
@@ -4,13 +4,22 @@ import (
     "math/bits"
 )
 
-type historyType uint64
+const historyWordSize = 64  // history word is uint64 thanks to math/bits calculation
+const historyMaxWords = 4   // up to 256 samples
+const historyMaxCnt = 255   // counts of history are saturated at uint8 max
 
-func newHistory(flag bool) historyType {
-    if flag {
-        return historyType(^uint64(0))
+// historyType holds samples in multiple words, where LSB of word 0 is the latest sample
+type historyType []uint64
+
+func newHistory(words int, flag bool) historyType {
+    if words < 1 {
+        words = 1
+    } else if words > historyMaxWords {
+        words = historyMaxWords
     }
-    return historyType(uint64(0))
+    history := make(historyType, words)
+    history.fill(flag)
+    return history
 }
 
 func boolToUint64(flag bool) (ans uint64) {
@@ -20,54 +29,85 @@ func boolToUint64(flag bool) (ans uint64) {
     return ans
 }
 
-func (history *historyType) getPos(i int) bool {
-    mask := uint64(1) << i
-    return (uint64(*history) & mask) != uint64(0)
+func (history historyType) fill(flag bool) {
+    for i := range history {
+        history[i] = uint64(0) - boolToUint64(flag)
+    }
 }
 
-func (history *historyType) setPos(i int, flag bool) {
-    mask := uint64(1) << i
-    *history = historyType((uint64(*history) & ^mask) | (boolToUint64(flag) << i))
+func (history historyType) getPos(i int) bool {
+    mask := uint64(1) << (i % historyWordSize)
+    return (history[i / historyWordSize] & mask) != uint64(0)
 }
 
-func (history *historyType) unshift(flag bool) {
-    *history = historyType((uint64(*history) << 1) | boolToUint64(flag))
+func (history historyType) setPos(i int, flag bool) {
+    mask := uint64(1) << (i % historyWordSize)
+    w := i / historyWordSize
+    history[w] = (history[w] & ^mask) | (boolToUint64(flag) << (i % historyWordSize))
 }
 
-func (history *historyType) countRisingEdge(single bool) (count uint8) {
-    u64 := uint64(*history)
-    const evenMask = 0x5555555555555555
-    const oddMask  = 0xaaaaaaaaaaaaaaaa
-    // check rising at even column
-    u64e := u64 ^ evenMask
-    u64e = (u64e >> 1) | u64e
-    u64e = u64e & evenMask
-    // check rising at odd column
-    u64o := u64 ^ oddMask
-    u64o = (u64o >> 1) | u64o
-    u64o = u64o & oddMask
-    // merge even and odd (ignore MSB)
-    u64 = ^(u64o | u64e | (uint64(1) << 63))
-    // shortcuts
-    if u64 == uint64(0) {
-        return uint8(0)
-    } else if single {
-        return uint8(1)
+func (history historyType) unshift(flag bool) {
+    // carry MSB of lower word into upper word
+    for w := len(history) - 1; w > 0; w-- {
+        history[w] = (history[w] << 1) | (history[w - 1] >> (historyWordSize - 1))
+    }
+    history[0] = (history[0] << 1) | boolToUint64(flag)
+}
+
+func (history historyType) countRisingEdge(single bool) (count uint8) {
+    total := 0
+    for w, u64 := range history {
+        // older neighbor of each bit (LSB of upper word for MSB, MSB itself is ignored at the oldest word)
+        older := u64 >> 1
+        if w + 1 < len(history) {
+            older |= history[w + 1] << (historyWordSize - 1)
+        } else {
+            older |= uint64(1) << (historyWordSize - 1)
+        }
+        // now '1' indicates where rising edge is (pushed at newer, released at older)
+        u64 = u64 & ^older
+        // shortcut
+        if u64 != uint64(0) && single {
+            return uint8(1)
+        }
+        total += bits.OnesCount64(u64)
+    }
+    if total > historyMaxCnt {
+        total = historyMaxCnt
     }
-    // now '1' indicates where rising edge is
-    return uint8(bits.OnesCount64(u64))
+    return uint8(total)
 }
 
-func (history *historyType) recentStayReleasedCounts() uint8 {
-    u64 := uint64(*history)
-    return uint8(bits.TrailingZeros64(u64))
+func (history historyType) recentStayReleasedCounts() uint8 {
+    total := 0
+    for _, u64 := range history {
+        n := bits.TrailingZeros64(u64)
+        total += n
+        if n < historyWordSize {
+            break
+        }
+    }
+    if total > historyMaxCnt {
+        total = historyMaxCnt
+    }
+    return uint8(total)
 }
 
-func (history *historyType) recentStayPushedCounts() uint8 {
-    u64 := uint64(*history)
+func (history historyType) recentStayPushedCounts() uint8 {
     // shortcut for very usual history case
-    if u64 == uint64(0) {
+    if history[0] == uint64(0) {
         return uint8(0)
     }
-    return uint8(bits.TrailingZeros64(^u64))
+    total := 0
+    for _, u64 := range history {
+        n := bits.TrailingZeros64(^u64)
+        total += n
+        if n < historyWordSize {
+            break
+        }
+    }
+    if total > historyMaxCnt {
+        total = historyMaxCnt
+    }
+    return uint8(total)
 }
@@ -0,0 +1,100 @@
+package buttons
+
+import (
+    "testing"
+)
+
+func TestHistoryUnshiftCarry(t *testing.T) {
+    history := newHistory(3, false)
+    history.unshift(true)
+    for i := 0; i < historyWordSize; i++ {
+        history.unshift(false)
+    }
+    if !history.getPos(historyWordSize) || history[1] != 1 || history[0] != 0 {
+        t.Fatalf("history %x not carried to word 1", []uint64(history))
+    }
+    for i := 0; i < historyWordSize; i++ {
+        history.unshift(false)
+    }
+    if !history.getPos(2 * historyWordSize) || history[2] != 1 || history[1] != 0 {
+        t.Fatalf("history %x not carried to word 2", []uint64(history))
+    }
+    // carried out of the oldest word
+    for i := 0; i < historyWordSize; i++ {
+        history.unshift(false)
+    }
+    if history[0] != 0 || history[1] != 0 || history[2] != 0 {
+        t.Fatalf("history %x not shifted out", []uint64(history))
+    }
+}
+
+func TestHistoryRisingEdge(t *testing.T) {
+    history := newHistory(2, false)
+    // pushed at 63 (newer) after released at 64 (older): edge across the words
+    history.setPos(historyWordSize - 1, true)
+    if cnt := history.countRisingEdge(false); cnt != 1 {
+        t.Fatalf("%d rising edges across words, want 1", cnt)
+    }
+    history.setPos(historyWordSize, true)
+    history.setPos(historyWordSize + 1, true)
+    if cnt := history.countRisingEdge(false); cnt != 1 {
+        t.Fatalf("%d rising edges of push over words, want 1", cnt)
+    }
+    // the oldest sample has no older neighbor
+    history.fill(false)
+    history.setPos(2 * historyWordSize - 1, true)
+    if cnt := history.countRisingEdge(false); cnt != 0 {
+        t.Fatalf("%d rising edges at the oldest sample, want 0", cnt)
+    }
+    // every other sample pushed
+    for i := 0; i < 2 * historyWordSize; i++ {
+        history.setPos(i, i % 2 == 0)
+    }
+    if cnt := history.countRisingEdge(false); cnt != historyWordSize {
+        t.Fatalf("%d rising edges, want %d", cnt, historyWordSize)
+    }
+    if cnt := history.countRisingEdge(true); cnt != 1 {
+        t.Fatalf("%d rising edges by single, want 1", cnt)
+    }
+}
+
+func TestHistoryStayCounts(t *testing.T) {
+    history := newHistory(historyMaxWords, false)
+    for i := 0; i < 100; i++ {
+        history.unshift(true)
+    }
+    if cnt := history.recentStayPushedCounts(); cnt != 100 {
+        t.Fatalf("stay pushed %d, want 100", cnt)
+    }
+    history.unshift(false)
+    if cnt := history.recentStayPushedCounts(); cnt != 0 {
+        t.Fatalf("stay pushed %d after release, want 0", cnt)
+    }
+    history.fill(true)
+    if cnt := history.recentStayPushedCounts(); cnt != historyMaxCnt {
+        t.Fatalf("stay pushed %d of 256 samples, want saturated %d", cnt, historyMaxCnt)
+    }
+    history.fill(false)
+    if cnt := history.recentStayReleasedCounts(); cnt != historyMaxCnt {
+        t.Fatalf("stay released %d of 256 samples, want saturated %d", cnt, historyMaxCnt)
+    }
+    history = newHistory(3, true)
+    if cnt := history.recentStayPushedCounts(); cnt != 3 * historyWordSize {
+        t.Fatalf("stay pushed %d of 3 words, want %d", cnt, 3 * historyWordSize)
+    }
+}
+
+func TestLongPushOverWord(t *testing.T) {
+    pin := &testPin{true}
+    buttons := New("test", NewButton("a", pin, NewButtonConfig(false, true, 1, 5, 0, 2, 100, 200)))
+    scanN(buttons, 1)
+    pin.level = false
+    scanN(buttons, 250) // scan 1 to 250
+    pin.level = true
+    scanN(buttons, 10)
+    checkEvents(t, drainTestEvents(buttons), []testEvent {
+        {"a", EVT_LONG, 100},
+        {"a", EVT_LONG_LONG, 200},
+        {"a", EVT_LONG_RELEASE, 251},
+    })
+}
@@ -0,0 +1,112 @@
+package buttons
+
+// SetIdleSuspend makes ScanPeriodic call suspend after idleScans continuous scans with nothing going on
+// (all buttons released, no click waiting to be determined, no chord in progress, no pending event to flush).
+// suspend is expected to stop the periodic scan and enable edge interrupts of the input pins calling Wake().
+// resume is expected to restart the periodic scan and then disable the edge interrupts. If it returns error,
+// Buttons stays suspended to retry at the next Wake() and counts it (GetResumeErrorCount()).
+// Both are called in interrupt, thus they must not allocate. Call it before starting the periodic scan
+// (nil suspend disables the suspension)
+func (buttons *Buttons) SetIdleSuspend(idleScans uint32, suspend func(), resume func() error) {
+    buttons.idleScans = idleScans
+    buttons.idleCnt = 0
+    buttons.suspend = suspend
+    buttons.resume = resume
+}
+
+// Wake resumes the scan suspended by idle and scans immediately not to lose the first push.
+// It is called from edge interrupts of input pins and does nothing unless suspended
+func (buttons *Buttons) Wake() {
+    if buttons.tryResume() {
+        ScanPeriodic(buttons)
+    }
+}
+
+func (buttons *Buttons) IsSuspended() bool {
+    return buttons.suspended.Load()
+}
+
+// GetResumeErrorCount returns the number of errors returned by resume given to SetIdleSuspend()
+func (buttons *Buttons) GetResumeErrorCount() uint32 {
+    return buttons.resumeErrCnt.Load()
+}
+
+// tryResume calls resume if suspended, returns true if resumed
+func (buttons *Buttons) tryResume() bool {
+    if !buttons.suspended.CompareAndSwap(true, false) {
+        return false
+    }
+    if err := buttons.resume(); err != nil {
+        buttons.resumeErrCnt.Add(1)
+        buttons.suspended.Store(true)
+        return false
+    }
+    return true
+}
+
+func (buttons *Buttons) checkIdle() {
+    if buttons.suspend == nil {
+        return
+    }
+    if !buttons.idle() {
+        buttons.idleCnt = 0
+        return
+    }
+    buttons.idleCnt++
+    if buttons.idleCnt < buttons.idleScans {
+        return
+    }
+    buttons.idleCnt = 0
+    buttons.suspended.Store(true)
+    buttons.suspend()
+    // an edge before suspend() enables the interrupts is missed, check inputs again
+    if buttons.inputActive() {
+        buttons.tryResume()
+    }
+}
+
+func (buttons *Buttons) idle() bool {
+    if buttons.overflowPending {
+        return false
+    }
+    for _, button := range buttons.getButtons() {
+        if !button.idle() {
+            return false
+        }
+    }
+    for _, chord := range buttons.getChords() {
+        if chord.active {
+            return false
+        }
+    }
+    // encoders are not checked: one resting between detents must not keep the scan running,
+    // and its next transition is caught by inputActive() or the edge interrupts
+    return true
+}
+
+// inputActive tells any button is pushed or any encoder is moved now
+func (buttons *Buttons) inputActive() bool {
+    var snapshot uint32
+    bank := buttons.bank.Load()
+    if bank != nil {
+        snapshot = (*bank).Get()
+    }
+    for _, button := range buttons.getButtons() {
+        if button.rawPushed(snapshot, bank != nil) {
+            return true
+        }
+    }
+    for _, encoder := range buttons.getEncoders() {
+        if encoder.moved() {
+            return true
+        }
+    }
+    return false
+}
+
+// idle tells the button is released and no click is waiting to be determined
+func (button *Button) idle() bool {
+    return !button.pressed && !button.longFired && !button.hasCoalesced && button.heldCnt == 0 && button.pending.Load() == nil &&
+        button.history.recentStayReleasedCounts() >= button.config.filterSize &&
+        button.filtered.countRisingEdge(true) == 0
+}
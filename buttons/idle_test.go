@@ -0,0 +1,103 @@
+package buttons
+
+import (
+    "errors"
+    "testing"
+)
+
+// testScanner models the periodic scan stopped by suspend and restarted by resume
+type testScanner struct {
+    running    bool
+    suspendCnt int
+    resumeCnt  int
+    resumeErr  error
+}
+
+func (scanner *testScanner) setup(buttons *Buttons, idleScans uint32) {
+    scanner.running = true
+    buttons.SetIdleSuspend(idleScans,
+        func() {
+            scanner.suspendCnt++
+            scanner.running = false
+        },
+        func() error {
+            scanner.resumeCnt++
+            if scanner.resumeErr != nil {
+                return scanner.resumeErr
+            }
+            scanner.running = true
+            return nil
+        },
+    )
+}
+
+func (scanner *testScanner) scan(buttons *Buttons, n int) {
+    for i := 0; i < n && scanner.running; i++ {
+        ScanPeriodic(buttons)
+    }
+}
+
+func TestIdleSuspendAndWake(t *testing.T) {
+    pin := &testPin{true}
+    buttons := New("test", NewButton("c", pin, DefaultButtonMultiConfig))
+    scanner := &testScanner{}
+    scanner.setup(buttons, 5)
+    scanner.scan(buttons, 20)
+    if scanner.suspendCnt != 1 || !buttons.IsSuspended() {
+        t.Fatalf("suspended %d times", scanner.suspendCnt)
+    }
+    // edge interrupt (bouncing) wakes once and the first push is scanned immediately
+    pin.level = false
+    buttons.Wake()
+    buttons.Wake()
+    if scanner.resumeCnt != 1 || buttons.IsSuspended() {
+        t.Fatalf("resumed %d times", scanner.resumeCnt)
+    }
+    scanner.scan(buttons, 2)
+    pin.level = true
+    // suspended again after the click is determined
+    scanner.scan(buttons, 100)
+    events := drainEvents(buttons)
+    if len(events) != 1 || events[0].Type != EVT_SINGLE {
+        t.Fatalf("unexpected events %v", events)
+    }
+    if scanner.suspendCnt != 2 {
+        t.Fatalf("suspended %d times", scanner.suspendCnt)
+    }
+}
+
+func TestIdleEdgeAtSuspend(t *testing.T) {
+    pin := &testPin{true}
+    buttons := New("test", NewButton("c", pin, DefaultButtonSingleConfig))
+    scanner := &testScanner{}
+    scanner.setup(buttons, 3)
+    // pushed while suspend() enables the edge interrupts
+    buttons.suspend = func() {
+        scanner.suspendCnt++
+        scanner.running = false
+        pin.level = false
+    }
+    scanner.scan(buttons, 10)
+    if scanner.suspendCnt != 1 || scanner.resumeCnt != 1 || buttons.IsSuspended() {
+        t.Fatalf("suspended %d times, resumed %d times", scanner.suspendCnt, scanner.resumeCnt)
+    }
+}
+
+func TestIdleResumeError(t *testing.T) {
+    pin := &testPin{true}
+    buttons := New("test", NewButton("c", pin, DefaultButtonSingleConfig))
+    scanner := &testScanner{}
+    scanner.setup(buttons, 3)
+    scanner.scan(buttons, 10)
+    scanner.resumeErr = errors.New("restart failed")
+    buttons.Wake()
+    if !buttons.IsSuspended() || buttons.GetResumeErrorCount() != 1 {
+        t.Fatalf("suspended %v, %d errors", buttons.IsSuspended(), buttons.GetResumeErrorCount())
+    }
+    // retried at the next edge
+    scanner.resumeErr = nil
+    buttons.Wake()
+    if buttons.IsSuspended() || scanner.resumeCnt != 2 {
+        t.Fatalf("suspended %v, resumed %d times", buttons.IsSuspended(), scanner.resumeCnt)
+    }
+}
@@ -0,0 +1,276 @@
+package buttons
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+    "unicode"
+    "unicode/utf16"
+)
+
+// minimal JSON reader/writer for ButtonConfig and Layout, not to depend on reflection of encoding/json in TinyGo
+
+type jsonParser struct {
+    data []byte
+    pos  int
+}
+
+// parseJSON returns map[string]interface{}, []interface{}, string, float64, bool or nil
+func parseJSON(data []byte) (interface{}, error) {
+    parser := &jsonParser{data: data}
+    value, err := parser.parseValue()
+    if err != nil {
+        return nil, err
+    }
+    parser.skipSpace()
+    if parser.pos < len(parser.data) {
+        return nil, parser.errorf("unexpected data after value")
+    }
+    return value, nil
+}
+
+func (parser *jsonParser) errorf(format string, args ...interface{}) error {
+    return fmt.Errorf("json at %d: %s", parser.pos, fmt.Sprintf(format, args...))
+}
+
+func (parser *jsonParser) skipSpace() {
+    for parser.pos < len(parser.data) && strings.IndexByte(" \t\r\n", parser.data[parser.pos]) >= 0 {
+        parser.pos++
+    }
+}
+
+func (parser *jsonParser) consume(literal string) bool {
+    end := parser.pos + len(literal)
+    if end <= len(parser.data) && string(parser.data[parser.pos:end]) == literal {
+        parser.pos += len(literal)
+        return true
+    }
+    return false
+}
+
+func (parser *jsonParser) parseValue() (interface{}, error) {
+    parser.skipSpace()
+    if parser.pos >= len(parser.data) {
+        return nil, parser.errorf("unexpected end")
+    }
+    switch c := parser.data[parser.pos]; {
+    case c == '{':
+        return parser.parseObject()
+    case c == '[':
+        return parser.parseArray()
+    case c == '"':
+        return parser.parseString()
+    case c == '-' || (c >= '0' && c <= '9'):
+        return parser.parseNumber()
+    case parser.consume("true"):
+        return true, nil
+    case parser.consume("false"):
+        return false, nil
+    case parser.consume("null"):
+        return nil, nil
+    }
+    return nil, parser.errorf("unexpected character %q", parser.data[parser.pos])
+}
+
+func (parser *jsonParser) parseObject() (interface{}, error) {
+    object := map[string]interface{}{}
+    parser.pos++ // '{'
+    parser.skipSpace()
+    if parser.consume("}") {
+        return object, nil
+    }
+    for {
+        parser.skipSpace()
+        if parser.pos >= len(parser.data) || parser.data[parser.pos] != '"' {
+            return nil, parser.errorf("object key expected")
+        }
+        key, err := parser.parseString()
+        if err != nil {
+            return nil, err
+        }
+        parser.skipSpace()
+        if !parser.consume(":") {
+            return nil, parser.errorf("':' expected")
+        }
+        value, err := parser.parseValue()
+        if err != nil {
+            return nil, err
+        }
+        object[key] = value
+        parser.skipSpace()
+        if parser.consume("}") {
+            return object, nil
+        }
+        if !parser.consume(",") {
+            return nil, parser.errorf("',' or '}' expected")
+        }
+    }
+}
+
+func (parser *jsonParser) parseArray() (interface{}, error) {
+    array := []interface{}{}
+    parser.pos++ // '['
+    parser.skipSpace()
+    if parser.consume("]") {
+        return array, nil
+    }
+    for {
+        value, err := parser.parseValue()
+        if err != nil {
+            return nil, err
+        }
+        array = append(array, value)
+        parser.skipSpace()
+        if parser.consume("]") {
+            return array, nil
+        }
+        if !parser.consume(",") {
+            return nil, parser.errorf("',' or ']' expected")
+        }
+    }
+}
+
+func (parser *jsonParser) parseString() (string, error) {
+    parser.pos++ // '"'
+    var sb strings.Builder
+    for parser.pos < len(parser.data) {
+        c := parser.data[parser.pos]
+        parser.pos++
+        switch {
+        case c == '"':
+            return sb.String(), nil
+        case c != '\\':
+            sb.WriteByte(c)
+        case parser.pos >= len(parser.data):
+            return "", parser.errorf("unexpected end in string")
+        default:
+            e := parser.data[parser.pos]
+            parser.pos++
+            switch e {
+            case '"', '\\', '/':
+                sb.WriteByte(e)
+            case 'b':
+                sb.WriteByte('\b')
+            case 'f':
+                sb.WriteByte('\f')
+            case 'n':
+                sb.WriteByte('\n')
+            case 'r':
+                sb.WriteByte('\r')
+            case 't':
+                sb.WriteByte('\t')
+            case 'u':
+                r, err := parser.parseHex4()
+                if err != nil {
+                    return "", err
+                }
+                // high surrogate followed by low surrogate makes a pair, lone surrogate is replaced by U+FFFD
+                if utf16.IsSurrogate(r) {
+                    r2 := rune(-1)
+                    next := parser.pos
+                    if parser.consume("\\u") {
+                        if r2, err = parser.parseHex4(); err != nil {
+                            return "", err
+                        }
+                    }
+                    if pair := utf16.DecodeRune(r, r2); pair != unicode.ReplacementChar {
+                        r = pair
+                    } else {
+                        r = unicode.ReplacementChar
+                        // the next escape is read by itself
+                        parser.pos = next
+                    }
+                }
+                sb.WriteRune(r)
+            default:
+                return "", parser.errorf("invalid escape")
+            }
+        }
+    }
+    return "", parser.errorf("unexpected end in string")
+}
+
+// parseHex4 reads 4 hex digits of \u escape
+func (parser *jsonParser) parseHex4() (rune, error) {
+    if parser.pos + 4 > len(parser.data) {
+        return 0, parser.errorf("unexpected end in string")
+    }
+    r, err := strconv.ParseUint(string(parser.data[parser.pos:parser.pos + 4]), 16, 16)
+    if err != nil {
+        return 0, parser.errorf("invalid escape")
+    }
+    parser.pos += 4
+    return rune(r), nil
+}
+
+func (parser *jsonParser) parseNumber() (interface{}, error) {
+    start := parser.pos
+    for parser.pos < len(parser.data) && strings.IndexByte("+-0123456789.eE", parser.data[parser.pos]) >= 0 {
+        parser.pos++
+    }
+    number, err := strconv.ParseFloat(string(parser.data[start:parser.pos]), 64)
+    if err != nil {
+        return nil, parser.errorf("invalid number")
+    }
+    return number, nil
+}
+
+func quoteJSON(s string) string {
+    var sb strings.Builder
+    sb.WriteByte('"')
+    for _, r := range s {
+        switch {
+        case r == '"' || r == '\\':
+            sb.WriteByte('\\')
+            sb.WriteRune(r)
+        case r < 0x20:
+            sb.WriteString(fmt.Sprintf("\\u%04x", r))
+        default:
+            sb.WriteRune(r)
+        }
+    }
+    sb.WriteByte('"')
+    return sb.String()
+}
+
+// helpers to read parsed JSON values
+
+func jsonToUint8(key string, value interface{}) (uint8, error) {
+    number, ok := value.(float64)
+    if !ok || number < 0 || number > 255 || number != float64(uint8(number)) {
+        return 0, fmt.Errorf("%s: integer 0 to 255 expected", key)
+    }
+    return uint8(number), nil
+}
+
+func jsonToBool(key string, value interface{}) (bool, error) {
+    flag, ok := value.(bool)
+    if !ok {
+        return false, fmt.Errorf("%s: bool expected", key)
+    }
+    return flag, nil
+}
+
+func jsonToString(key string, value interface{}) (string, error) {
+    s, ok := value.(string)
+    if !ok {
+        return "", fmt.Errorf("%s: string expected", key)
+    }
+    return s, nil
+}
+
+func jsonToArray(key string, value interface{}) ([]interface{}, error) {
+    array, ok := value.([]interface{})
+    if !ok {
+        return nil, fmt.Errorf("%s: array expected", key)
+    }
+    return array, nil
+}
+
+func jsonToObject(key string, value interface{}) (map[string]interface{}, error) {
+    object, ok := value.(map[string]interface{})
+    if !ok {
+        return nil, fmt.Errorf("%s: object expected", key)
+    }
+    return object, nil
+}
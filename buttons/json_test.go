@@ -0,0 +1,68 @@
+package buttons
+
+import (
+    "testing"
+)
+
+func TestParseJSONString(t *testing.T) {
+    tests := []struct {
+        json string
+        want string
+    } {
+        {`"abc"`, "abc"},
+        {`"a\"b\\c\/d\n"`, "a\"b\\c/d\n"},
+        {`"é"`, "é"},
+        {`"😀"`, "\U0001f600"},
+        {`"\ud83dx"`, "�x"},
+        {`"\ude00"`, "�"},
+        {`"\ud83dA"`, "�A"},
+        {`"\ud83d😀"`, "�\U0001f600"},
+    }
+    for _, test := range tests {
+        value, err := parseJSON([]byte(test.json))
+        if err != nil {
+            t.Fatalf("%s: %s", test.json, err)
+        }
+        if value != test.want {
+            t.Fatalf("%s: got %q, want %q", test.json, value, test.want)
+        }
+    }
+}
+
+func TestParseJSONMalformed(t *testing.T) {
+    for _, json := range []string {
+        ``,
+        `{`,
+        `{"a"}`,
+        `{"a":1,}`,
+        `{a:1}`,
+        `[1 2]`,
+        `"abc`,
+        `"\x"`,
+        `"\u12"`,
+        `"\uzzzz"`,
+        `tru`,
+        `nul`,
+        `1.2.3`,
+        `{} {}`,
+    } {
+        if _, err := parseJSON([]byte(json)); err == nil {
+            t.Fatalf("%q: no error", json)
+        }
+    }
+}
+
+func TestParseJSONValue(t *testing.T) {
+    value, err := parseJSON([]byte(` {"a": [true, false, null, -1.5e1], "b": {}} `))
+    if err != nil {
+        t.Fatal(err)
+    }
+    object := value.(map[string]interface{})
+    array := object["a"].([]interface{})
+    if len(array) != 4 || array[0] != true || array[1] != false || array[2] != nil || array[3] != -15.0 {
+        t.Fatalf("unexpected %v", array)
+    }
+    if len(object["b"].(map[string]interface{})) != 0 {
+        t.Fatalf("unexpected %v", object["b"])
+    }
+}
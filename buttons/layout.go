@@ -0,0 +1,229 @@
+package buttons
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+)
+
+// presets of ButtonConfig referred by name in Layout
+var presets = []struct {
+    name   string
+    config *ButtonConfig
+} {
+    {"single", DefaultButtonSingleConfig},
+    {"singleRepeat", DefaultButtonSingleRepeatConfig},
+    {"multi", DefaultButtonMultiConfig},
+}
+
+// Preset returns preset ButtonConfig of name ("single", "singleRepeat" or "multi"), nil if not found
+func Preset(name string) *ButtonConfig {
+    for _, preset := range presets {
+        if preset.name == name {
+            return preset.config
+        }
+    }
+    return nil
+}
+
+// PresetName returns the name of preset equal to config, "" if none
+func PresetName(config *ButtonConfig) string {
+    for _, preset := range presets {
+        if equalConfig(preset.config, config) {
+            return preset.name
+        }
+    }
+    return ""
+}
+
+type LayoutButton struct {
+    Name   string
+    Pin    int           // pin id (e.g. GPIO number) to be resolved by Layout.Build()
+    Preset string        // name of preset ButtonConfig, Config is used if empty
+    Config *ButtonConfig
+}
+
+// Layout describes the buttons of Buttons to be saved, loaded or shown in UI
+type Layout struct {
+    Name    string
+    Buttons []LayoutButton
+}
+
+// AddButton appends a button to layout, which refers to preset if config is equal to one of them
+func (layout *Layout) AddButton(name string, pin int, config *ButtonConfig) {
+    layoutButton := LayoutButton {
+        Name: name,
+        Pin: pin,
+        Preset: PresetName(config),
+    }
+    if layoutButton.Preset == "" {
+        layoutButton.Config = config
+    }
+    layout.Buttons = append(layout.Buttons, layoutButton)
+}
+
+func (layoutButton *LayoutButton) config() (*ButtonConfig, error) {
+    if layoutButton.Preset != "" {
+        config := Preset(layoutButton.Preset)
+        if config == nil {
+            return nil, fmt.Errorf("button %s: unknown preset %s", layoutButton.Name, layoutButton.Preset)
+        }
+        return config, nil
+    }
+    if layoutButton.Config == nil {
+        return nil, fmt.Errorf("button %s: neither preset nor config", layoutButton.Name)
+    }
+    return layoutButton.Config, nil
+}
+
+// Build makes Buttons of layout, where pinOf returns Pin of pin id
+func (layout *Layout) Build(pinOf func(id int) Pin) (*Buttons, error) {
+    buttonSlice := []*Button{}
+    for i := range layout.Buttons {
+        layoutButton := &layout.Buttons[i]
+        config, err := layoutButton.config()
+        if err != nil {
+            return nil, err
+        }
+        pin := pinOf(layoutButton.Pin)
+        if pin == nil {
+            return nil, fmt.Errorf("button %s: pin %d not available", layoutButton.Name, layoutButton.Pin)
+        }
+        buttonSlice = append(buttonSlice, NewButton(layoutButton.Name, pin, config))
+    }
+    return New(layout.Name, buttonSlice...), nil
+}
+
+// MarshalJSON encodes layout as {"name":...,"buttons":[{"name":...,"pin":...,"preset":... or "config":{...}}, ...]}
+func (layout *Layout) MarshalJSON() ([]byte, error) {
+    items := []string{}
+    for _, layoutButton := range layout.Buttons {
+        item := "{\"name\":" + quoteJSON(layoutButton.Name) + ",\"pin\":" + strconv.Itoa(layoutButton.Pin)
+        if layoutButton.Preset != "" {
+            item += ",\"preset\":" + quoteJSON(layoutButton.Preset)
+        } else if layoutButton.Config != nil {
+            config, _ := layoutButton.Config.MarshalJSON()
+            item += ",\"config\":" + string(config)
+        }
+        items = append(items, item + "}")
+    }
+    return []byte("{\"name\":" + quoteJSON(layout.Name) + ",\"buttons\":[" + strings.Join(items, ",") + "]}"), nil
+}
+
+func (layout *Layout) UnmarshalJSON(data []byte) error {
+    value, err := parseJSON(data)
+    if err != nil {
+        return err
+    }
+    object, err := jsonToObject("Layout", value)
+    if err != nil {
+        return err
+    }
+    newLayout := Layout{}
+    if newLayout.Name, err = jsonToString("name", object["name"]); err != nil {
+        return err
+    }
+    array, err := jsonToArray("buttons", object["buttons"])
+    if err != nil {
+        return err
+    }
+    for _, item := range array {
+        buttonObject, err := jsonToObject("buttons", item)
+        if err != nil {
+            return err
+        }
+        layoutButton := LayoutButton{}
+        if layoutButton.Name, err = jsonToString("buttons.name", buttonObject["name"]); err != nil {
+            return err
+        }
+        pin, ok := buttonObject["pin"].(float64)
+        if !ok || pin != float64(int(pin)) {
+            return fmt.Errorf("button %s: integer pin expected", layoutButton.Name)
+        }
+        layoutButton.Pin = int(pin)
+        if preset, ok := buttonObject["preset"]; ok {
+            if layoutButton.Preset, err = jsonToString("buttons.preset", preset); err != nil {
+                return err
+            }
+        } else if configValue, ok := buttonObject["config"]; ok {
+            configObject, err := jsonToObject("buttons.config", configValue)
+            if err != nil {
+                return err
+            }
+            if layoutButton.Config, err = configFromJSON(configObject); err != nil {
+                return err
+            }
+        }
+        if _, err := layoutButton.config(); err != nil {
+            return err
+        }
+        newLayout.Buttons = append(newLayout.Buttons, layoutButton)
+    }
+    *layout = newLayout
+    return nil
+}
+
+// MarshalText encodes layout as lines of key=value: "layout=<name>" followed by
+// "button=<name> pin=<id> preset=<preset>" or "button=<name> pin=<id> <key=value of ButtonConfig>" for each button.
+// Names must not contain space
+func (layout *Layout) MarshalText() ([]byte, error) {
+    if strings.ContainsAny(layout.Name, " \t\r\n") {
+        return nil, fmt.Errorf("layout name must not contain space: %q", layout.Name)
+    }
+    lines := []string{"layout=" + layout.Name}
+    for _, layoutButton := range layout.Buttons {
+        if strings.ContainsAny(layoutButton.Name, " \t\r\n") {
+            return nil, fmt.Errorf("button name must not contain space: %q", layoutButton.Name)
+        }
+        fields := []string{"button=" + layoutButton.Name, "pin=" + strconv.Itoa(layoutButton.Pin)}
+        if layoutButton.Preset != "" {
+            fields = append(fields, "preset=" + layoutButton.Preset)
+        } else if layoutButton.Config != nil {
+            fields = append(fields, layoutButton.Config.textFields()...)
+        }
+        lines = append(lines, strings.Join(fields, " "))
+    }
+    return []byte(strings.Join(lines, "\n") + "\n"), nil
+}
+
+func (layout *Layout) UnmarshalText(text []byte) error {
+    newLayout := Layout{}
+    for i, line := range strings.Split(string(text), "\n") {
+        fields := strings.Fields(line)
+        if len(fields) == 0 {
+            continue
+        }
+        key, value, _ := strings.Cut(fields[0], "=")
+        if i == 0 || key == "layout" {
+            if key != "layout" || len(fields) != 1 {
+                return fmt.Errorf("line %d: layout=<name> expected", i + 1)
+            }
+            newLayout.Name = value
+            continue
+        }
+        if key != "button" || len(fields) < 2 {
+            return fmt.Errorf("line %d: button=<name> pin=<id> expected", i + 1)
+        }
+        layoutButton := LayoutButton{Name: value}
+        pinKey, pinValue, _ := strings.Cut(fields[1], "=")
+        pin, err := strconv.Atoi(pinValue)
+        if pinKey != "pin" || err != nil {
+            return fmt.Errorf("line %d: pin=<id> expected", i + 1)
+        }
+        layoutButton.Pin = pin
+        if len(fields) == 2 {
+            return fmt.Errorf("line %d: preset=<preset> or config key=value expected", i + 1)
+        }
+        if len(fields) == 3 && strings.HasPrefix(fields[2], "preset=") {
+            layoutButton.Preset = strings.TrimPrefix(fields[2], "preset=")
+        } else if layoutButton.Config, err = configFromText(fields[2:]); err != nil {
+            return fmt.Errorf("line %d: %s", i + 1, err)
+        }
+        if _, err := layoutButton.config(); err != nil {
+            return err
+        }
+        newLayout.Buttons = append(newLayout.Buttons, layoutButton)
+    }
+    *layout = newLayout
+    return nil
+}
@@ -0,0 +1,92 @@
+package buttons
+
+import (
+    "testing"
+)
+
+func testLayout() *Layout {
+    layout := &Layout{Name: "panel"}
+    layout.AddButton("reset", 0, DefaultButtonSingleConfig)
+    layout.AddButton("left", 3, DefaultButtonSingleRepeatConfig)
+    layout.AddButton("center", 4, DefaultButtonMultiConfig)
+    layout.AddButton("up", 5, customRepeatConfig())
+    layout.AddButton("menu", 6, customConfig())
+    return layout
+}
+
+func checkLayout(t *testing.T, got, want *Layout) {
+    t.Helper()
+    if got.Name != want.Name || len(got.Buttons) != len(want.Buttons) {
+        t.Fatalf("got %v, want %v", got, want)
+    }
+    for i, button := range got.Buttons {
+        wantButton := want.Buttons[i]
+        if button.Name != wantButton.Name || button.Pin != wantButton.Pin || button.Preset != wantButton.Preset {
+            t.Fatalf("button %d: got %v, want %v", i, button, wantButton)
+        }
+        if (button.Config == nil) != (wantButton.Config == nil) ||
+           (button.Config != nil && !equalConfig(button.Config, wantButton.Config)) {
+            t.Fatalf("button %d: config differs", i)
+        }
+    }
+}
+
+func TestLayoutRoundTrip(t *testing.T) {
+    layout := testLayout()
+    if layout.Buttons[0].Preset != "single" || layout.Buttons[4].Preset != "" {
+        t.Fatalf("unexpected presets %v", layout.Buttons)
+    }
+    data, err := layout.MarshalJSON()
+    if err != nil {
+        t.Fatal(err)
+    }
+    decoded := &Layout{}
+    if err := decoded.UnmarshalJSON(data); err != nil {
+        t.Fatal(err)
+    }
+    checkLayout(t, decoded, layout)
+    text, err := layout.MarshalText()
+    if err != nil {
+        t.Fatal(err)
+    }
+    decoded = &Layout{}
+    if err := decoded.UnmarshalText(text); err != nil {
+        t.Fatal(err)
+    }
+    checkLayout(t, decoded, layout)
+    buttons, err := decoded.Build(func(id int) Pin { return &testPin{true} })
+    if err != nil {
+        t.Fatal(err)
+    }
+    if buttons.GetName() != "panel" || len(buttons.getButtons()) != 5 {
+        t.Fatal("unexpected Buttons built")
+    }
+}
+
+func TestLayoutMalformed(t *testing.T) {
+    for _, json := range []string {
+        `[]`,
+        `{"name":"x","buttons":{}}`,
+        `{"name":"x","buttons":[{"name":"a","pin":1}]}`,
+        `{"name":"x","buttons":[{"name":"a","pin":1,"preset":"unknown"}]}`,
+        `{"name":"x","buttons":[{"name":"a","pin":"1","preset":"single"}]}`,
+        `{"name":"x","buttons":[{"name":"a","pin":1,"config":{"filterSize":-1}}]}`,
+    } {
+        if err := (&Layout{}).UnmarshalJSON([]byte(json)); err == nil {
+            t.Fatalf("%s: no error", json)
+        }
+    }
+    for _, text := range []string {
+        "button=a pin=1 preset=single\n",
+        "layout=x\nbutton=a pin=1\n",
+        "layout=x\nbutton=a preset=single\n",
+        "layout=x\nbutton=a pin=z preset=single\n",
+        "layout=x\nbutton=a pin=1 preset=unknown\n",
+        "layout=x\nbutton=a pin=1 filterSize=x\n",
+        "layout=x\nswitch=a pin=1 preset=single\n",
+    } {
+        if err := (&Layout{}).UnmarshalText([]byte(text)); err == nil {
+            t.Fatalf("%q: no error", text)
+        }
+    }
+}
@@ -0,0 +1,98 @@
+package buttons
+
+import (
+    "fmt"
+)
+
+// MatrixRow drives a row line of keypad matrix low, or releases it to high impedance
+// (e.g. GPIO switched between output low and input, or open-drain output). Rows must never be driven high,
+// otherwise a driven row and an idle row are shorted through two keys pushed on the same column
+type MatrixRow interface {
+    Drive()
+    Release()
+}
+
+// Matrix scans keypad matrix by driving a row low one by one (the others released) and reading columns pulled up.
+// It serves key levels as PinBank for NewBankButton() (bit of Bit(row, col), low while pushed as GPIO with pull-up).
+// Without diodes, 3 keys pushed at the corners of a rectangle make the 4th corner look pushed (ghosting) through the
+// released rows. All keys at such corners are flagged by GhostMask() and keep their previous levels until the ambiguity
+// is gone. With a diode in series with each key, there is no ghosting and SetDiodes(true) disables the masking
+type Matrix struct {
+    rows      []MatrixRow
+    cols      []Pin
+    diodes    bool
+    levels    uint32
+    ghostMask uint32
+}
+
+const matrixMaxKeys = 32
+
+func NewMatrix(rows []MatrixRow, cols []Pin) (*Matrix, error) {
+    if len(rows) == 0 || len(cols) == 0 || len(rows) * len(cols) > matrixMaxKeys {
+        return nil, fmt.Errorf("matrix %dx%d is out of range (up to %d keys)", len(rows), len(cols), matrixMaxKeys)
+    }
+    matrix := &Matrix {
+        rows: append([]MatrixRow{}, rows...),
+        cols: append([]Pin{}, cols...),
+        levels: ^uint32(0),
+    }
+    for _, row := range matrix.rows {
+        row.Release()
+    }
+    return matrix, nil
+}
+
+// SetDiodes tells keys have series diodes, then all keys pushed together are reported without ghost masking
+func (matrix *Matrix) SetDiodes(diodes bool) {
+    matrix.diodes = diodes
+}
+
+// Bit returns bit of the key at row and col to give NewBankButton()
+func (matrix *Matrix) Bit(row, col int) uint8 {
+    return uint8(row * len(matrix.cols) + col)
+}
+
+// Get scans all keys and returns their levels
+func (matrix *Matrix) Get() uint32 {
+    // === Scan pushed keys for each row ===
+    var rowBits [matrixMaxKeys]uint32 // bit c is set if key at col c pushed
+    var pushed uint32
+    for r, row := range matrix.rows {
+        row.Drive()
+        for c, col := range matrix.cols {
+            if !col.Get() {
+                rowBits[r] |= 1 << c
+                pushed |= 1 << matrix.Bit(r, c)
+            }
+        }
+        row.Release()
+    }
+    // === Detect ghosting (rows sharing 2 or more pushed columns) ===
+    var ghostMask uint32
+    for r1 := 0; !matrix.diodes && r1 < len(matrix.rows); r1++ {
+        for r2 := r1 + 1; r2 < len(matrix.rows); r2++ {
+            common := rowBits[r1] & rowBits[r2]
+            if common & (common - 1) == 0 { // less than 2 columns
+                continue
+            }
+            for c := range matrix.cols {
+                if common & (1 << c) != 0 {
+                    ghostMask |= 1 << matrix.Bit(r1, c) | 1 << matrix.Bit(r2, c)
+                }
+            }
+        }
+    }
+    matrix.ghostMask = ghostMask
+    // === Update levels except ghost keys ===
+    matrix.levels = (matrix.levels & ghostMask) | (^pushed & ^ghostMask)
+    return matrix.levels
+}
+
+// GhostMask returns bits of the keys ambiguous at the last scan (always 0 with diodes)
+func (matrix *Matrix) GhostMask() uint32 {
+    return matrix.ghostMask
+}
+
+func (matrix *Matrix) Ghosting() bool {
+    return matrix.ghostMask != 0
+}
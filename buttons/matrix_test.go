@@ -0,0 +1,123 @@
+package buttons
+
+import (
+    "testing"
+)
+
+// testKeypad models keypad matrix without diodes: a column is low if it connects to the driven row
+// through pushed keys, including paths through released rows (ghosting)
+type testKeypad struct {
+    keys    [4][4]bool
+    driven  [4]bool
+    diodes  bool
+    t       *testing.T
+}
+
+type testKeypadRow struct {
+    keypad *testKeypad
+    row    int
+}
+
+type testKeypadCol struct {
+    keypad *testKeypad
+    col    int
+}
+
+func (row *testKeypadRow) Drive() {
+    for r, driven := range row.keypad.driven {
+        if driven && r != row.row {
+            row.keypad.t.Fatalf("row %d driven while row %d is driven", row.row, r)
+        }
+    }
+    row.keypad.driven[row.row] = true
+}
+
+func (row *testKeypadRow) Release() {
+    row.keypad.driven[row.row] = false
+}
+
+func (col *testKeypadCol) Get() bool {
+    keypad := col.keypad
+    low := keypad.driven
+    var colLow [4]bool
+    for changed := true; changed; {
+        changed = false
+        for r := range keypad.keys {
+            for c := range keypad.keys[r] {
+                if !keypad.keys[r][c] {
+                    continue
+                }
+                if low[r] && !colLow[c] {
+                    colLow[c] = true
+                    changed = true
+                }
+                // current flows back to a released row unless blocked by diode
+                if !keypad.diodes && colLow[c] && !low[r] {
+                    low[r] = true
+                    changed = true
+                }
+            }
+        }
+    }
+    return !colLow[col.col]
+}
+
+func newTestMatrix(t *testing.T, diodes bool) (*testKeypad, *Matrix) {
+    keypad := &testKeypad{diodes: diodes, t: t}
+    rows := []MatrixRow{}
+    cols := []Pin{}
+    for i := 0; i < 4; i++ {
+        rows = append(rows, &testKeypadRow{keypad, i})
+        cols = append(cols, &testKeypadCol{keypad, i})
+    }
+    matrix, err := NewMatrix(rows, cols)
+    if err != nil {
+        t.Fatal(err)
+    }
+    matrix.SetDiodes(diodes)
+    return keypad, matrix
+}
+
+func TestMatrixGhost(t *testing.T) {
+    keypad, matrix := newTestMatrix(t, false)
+    keypad.keys[0][0] = true
+    keypad.keys[1][2] = true
+    levels := matrix.Get()
+    if levels != ^uint32(1 << matrix.Bit(0, 0) | 1 << matrix.Bit(1, 2)) || matrix.Ghosting() {
+        t.Fatalf("levels %032b ghost %032b", levels, matrix.GhostMask())
+    }
+    // 3rd key at a corner makes (1, 0) look pushed
+    keypad.keys[0][2] = true
+    levels = matrix.Get()
+    wantGhost := uint32(1 << matrix.Bit(0, 0) | 1 << matrix.Bit(0, 2) | 1 << matrix.Bit(1, 0) | 1 << matrix.Bit(1, 2))
+    if matrix.GhostMask() != wantGhost {
+        t.Fatalf("ghost %032b, want %032b", matrix.GhostMask(), wantGhost)
+    }
+    // ghost keys keep the previous levels
+    if levels != ^uint32(1 << matrix.Bit(0, 0) | 1 << matrix.Bit(1, 2)) {
+        t.Fatalf("levels %032b", levels)
+    }
+    keypad.keys[0][0] = false
+    levels = matrix.Get()
+    if levels != ^uint32(1 << matrix.Bit(0, 2) | 1 << matrix.Bit(1, 2)) || matrix.Ghosting() {
+        t.Fatalf("levels %032b ghost %032b", levels, matrix.GhostMask())
+    }
+}
+
+func TestMatrixDiodes(t *testing.T) {
+    keypad, matrix := newTestMatrix(t, true)
+    keypad.keys[0][0] = true
+    keypad.keys[0][2] = true
+    keypad.keys[1][0] = true
+    keypad.keys[1][2] = true
+    levels := matrix.Get()
+    if levels != ^uint32(1 << matrix.Bit(0, 0) | 1 << matrix.Bit(0, 2) | 1 << matrix.Bit(1, 0) | 1 << matrix.Bit(1, 2)) || matrix.Ghosting() {
+        t.Fatalf("levels %032b ghost %032b", levels, matrix.GhostMask())
+    }
+}
+
+func TestMatrixRange(t *testing.T) {
+    if _, err := NewMatrix(make([]MatrixRow, 5), make([]Pin, 7)); err == nil {
+        t.Fatal("no error for 35 keys")
+    }
+}
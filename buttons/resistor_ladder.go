@@ -0,0 +1,95 @@
+package buttons
+
+import (
+    "fmt"
+)
+
+// AnalogInput reads an analog level (e.g. machine.ADC)
+type AnalogInput interface {
+    Get() uint16
+}
+
+// LadderWindow maps the readings from Low to High to the buttons pushed (bit i of Mask for button i)
+// Mask can have 2 or more bits for a combination of buttons, and readings out of any window mean no button
+type LadderWindow struct {
+    Low  uint16
+    High uint16
+    Mask uint32
+}
+
+// ResistorLadder decodes buttons connected to a single analog input through a resistor ladder.
+// A window is entered when the reading comes inside it by hysteresis from its edges, and kept while
+// the reading stays within hysteresis outside it, so that readings near an edge don't flip every scan.
+// It serves as PinBank (bit i for button i, read at Get()), or as Sampler given to AddSampler()
+// with virtual pins of Pin(i) for NewButton(). Either way, the analog input is read once per scan
+type ResistorLadder struct {
+    input      AnalogInput
+    windows    []LadderWindow
+    hysteresis uint16
+    window     int
+    levels     uint32 // low while pushed at the last reading
+}
+
+const ladderMaxButtons = 32
+
+func NewResistorLadder(input AnalogInput, hysteresis uint16, windows ...LadderWindow) (*ResistorLadder, error) {
+    for i, w := range windows {
+        if w.Low > w.High {
+            return nil, fmt.Errorf("ladder window %d has Low %d above High %d", i, w.Low, w.High)
+        }
+        if int(w.High) - int(w.Low) < 2 * int(hysteresis) {
+            return nil, fmt.Errorf("ladder window %d is narrower than twice hysteresis %d", i, hysteresis)
+        }
+        for j := 0; j < i; j++ {
+            if w.Low <= windows[j].High && windows[j].Low <= w.High {
+                return nil, fmt.Errorf("ladder window %d overlaps window %d", i, j)
+            }
+        }
+    }
+    return &ResistorLadder {
+        input: input,
+        windows: append([]LadderWindow{}, windows...),
+        hysteresis: hysteresis,
+        window: -1,
+        levels: ^uint32(0),
+    }, nil
+}
+
+// Pin returns virtual pin of button index, which is low while pushed at the last Sample() (or Get())
+func (ladder *ResistorLadder) Pin(index uint8) (Pin, error) {
+    return newSampledPin(&ladder.levels, index, ladderMaxButtons)
+}
+
+// Sample reads the analog input
+func (ladder *ResistorLadder) Sample() {
+    ladder.Get()
+}
+
+// Get reads the analog input and returns levels of all buttons (low while pushed)
+func (ladder *ResistorLadder) Get() uint32 {
+    value := int(ladder.input.Get())
+    hysteresis := int(ladder.hysteresis)
+    // === Keep the current window within hysteresis outside it ===
+    if ladder.window >= 0 {
+        w := &ladder.windows[ladder.window]
+        if value >= int(w.Low) - hysteresis && value <= int(w.High) + hysteresis {
+            return ladder.levels
+        }
+    }
+    // === Enter a window by hysteresis inside it, otherwise no button ===
+    ladder.window = -1
+    ladder.levels = ^uint32(0)
+    for i, w := range ladder.windows {
+        if value >= int(w.Low) + hysteresis && value <= int(w.High) - hysteresis {
+            ladder.window = i
+            ladder.levels = ^w.Mask
+            break
+        }
+    }
+    return ladder.levels
+}
+
+// Pushed returns buttons pushed at the last reading
+func (ladder *ResistorLadder) Pushed() uint32 {
+    return ^ladder.levels
+}
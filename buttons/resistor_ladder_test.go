@@ -0,0 +1,101 @@
+package buttons
+
+import (
+    "testing"
+)
+
+type testAnalog struct {
+    value uint16
+    reads int
+}
+
+func (analog *testAnalog) Get() uint16 {
+    analog.reads++
+    return analog.value
+}
+
+func newTestLadder(t *testing.T) (*testAnalog, *ResistorLadder) {
+    analog := &testAnalog{value: 60000}
+    ladder, err := NewResistorLadder(analog, 500,
+        LadderWindow{0, 2000, 0b001},
+        LadderWindow{10000, 14000, 0b010},
+        LadderWindow{20000, 24000, 0b011}, // button 0 and 1 together
+    )
+    if err != nil {
+        t.Fatal(err)
+    }
+    return analog, ladder
+}
+
+func TestResistorLadderWindows(t *testing.T) {
+    analog, ladder := newTestLadder(t)
+    tests := []struct {
+        value  uint16
+        pushed uint32
+    } {
+        {60000, 0b000}, // no button
+        {10200, 0b000}, // inside window but within hysteresis from its edge: not entered
+        {12000, 0b010},
+        {14300, 0b010}, // out of window but within hysteresis: kept
+        {14600, 0b000},
+        {13800, 0b000}, // within hysteresis from the edge: not entered again
+        {13400, 0b010},
+        {22000, 0b011}, // two buttons
+        {1000, 0b001},
+        {60000, 0b000},
+    }
+    for i, test := range tests {
+        analog.value = test.value
+        if levels := ladder.Get(); levels != ^test.pushed || ladder.Pushed() != test.pushed {
+            t.Fatalf("step %d (%d): pushed %03b, want %03b", i, test.value, ladder.Pushed(), test.pushed)
+        }
+    }
+}
+
+func TestResistorLadderInvalid(t *testing.T) {
+    analog := &testAnalog{}
+    for _, windows := range [][]LadderWindow {
+        {{2000, 1000, 1}},
+        {{0, 800, 1}},
+        {{0, 2000, 1}, {1500, 4000, 2}},
+    } {
+        if _, err := NewResistorLadder(analog, 500, windows...); err == nil {
+            t.Fatalf("no error for %v", windows)
+        }
+    }
+    _, ladder := newTestLadder(t)
+    if _, err := ladder.Pin(32); err == nil {
+        t.Fatal("no error for pin 32")
+    }
+}
+
+func TestResistorLadderSampledOncePerScan(t *testing.T) {
+    analog, ladder := newTestLadder(t)
+    buttons := New("test")
+    for i := uint8(0); i < 2; i++ {
+        pin, err := ladder.Pin(i)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if err := buttons.AddButton(NewButton(string(rune('a' + i)), pin, DefaultButtonSingleConfig)); err != nil {
+            t.Fatal(err)
+        }
+    }
+    buttons.AddSampler(ladder)
+    // idle check after the scan reads the virtual pins, but not the analog input
+    buttons.SetIdleSuspend(1, func() {}, func() error { return nil })
+    scanN(buttons, 1)
+    if !buttons.IsSuspended() || analog.reads != 1 {
+        t.Fatalf("suspended %v, %d reads in 1 scan", buttons.IsSuspended(), analog.reads)
+    }
+    analog.value = 22000
+    buttons.Wake()
+    scanN(buttons, 2)
+    if analog.reads != 4 {
+        t.Fatalf("%d reads in 4 scans", analog.reads)
+    }
+    checkEvents(t, drainTestEvents(buttons), []testEvent {
+        {"a", EVT_SINGLE, 1},
+        {"b", EVT_SINGLE, 1},
+    })
+}
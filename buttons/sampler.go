@@ -0,0 +1,43 @@
+package buttons
+
+import (
+    "fmt"
+)
+
+// Sampler reads an input source (e.g. ShiftRegister, ResistorLadder) once per scan before the buttons are sampled.
+// Its virtual pins return the levels of the last Sample(), so that the source is read once however many pins are read
+type Sampler interface {
+    Sample()
+}
+
+// AddSampler registers sampler to be sampled every scan, it is safe while ScanPeriodic is running
+func (buttons *Buttons) AddSampler(sampler Sampler) {
+    buttons.mutex.Lock()
+    defer buttons.mutex.Unlock()
+    samplers := append(append([]Sampler{}, buttons.getSamplers()...), sampler)
+    buttons.samplers.Store(&samplers)
+}
+
+func (buttons *Buttons) getSamplers() []Sampler {
+    return *buttons.samplers.Load()
+}
+
+// sampledPin is a virtual pin reading bit of levels latched by a Sampler
+type sampledPin struct {
+    levels *uint32
+    bit    uint8
+}
+
+func newSampledPin(levels *uint32, bit uint8, bits int) (Pin, error) {
+    if int(bit) >= bits {
+        return nil, fmt.Errorf("bit %d is out of range (0 to %d)", bit, bits - 1)
+    }
+    return &sampledPin {
+        levels: levels,
+        bit: bit,
+    }, nil
+}
+
+func (pin *sampledPin) Get() bool {
+    return (*pin.levels >> pin.bit) & 1 != 0
+}
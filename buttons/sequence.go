@@ -0,0 +1,145 @@
+package buttons
+
+import (
+    "fmt"
+    "time"
+)
+
+type SequenceStep struct {
+    ButtonName string
+    Type       ButtonEventType
+}
+
+// SequencePattern recognizes ordered events of buttons (e.g. up, up, down, down, center) within a time window.
+// Events carrying RepeatCount and events of types not used by any step are ignored
+type SequencePattern struct {
+    name   string
+    steps  []SequenceStep
+    window uint64          // us
+    recent []SequenceStep  // latest relevant events, oldest first
+    times  []uint64        // timestamps of recent
+}
+
+func NewSequencePattern(name string, window time.Duration, step ...SequenceStep) *SequencePattern {
+    return &SequencePattern {
+        name: name,
+        steps: append([]SequenceStep{}, step...),
+        window: uint64(window / time.Microsecond),
+        recent: make([]SequenceStep, 0, len(step)),
+        times: make([]uint64, 0, len(step)),
+    }
+}
+
+func (pattern *SequencePattern) GetName() string {
+    return pattern.name
+}
+
+// Reset discards the partial match
+func (pattern *SequencePattern) Reset() {
+    pattern.recent = pattern.recent[:0]
+    pattern.times = pattern.times[:0]
+}
+
+func (pattern *SequencePattern) relevant(event *ButtonEvent) bool {
+    if event.RepeatCount > 0 {
+        return false
+    }
+    for _, step := range pattern.steps {
+        if step.Type == event.Type {
+            return true
+        }
+    }
+    return false
+}
+
+// expire drops events of partial match older than window at now
+func (pattern *SequencePattern) expire(now uint64) {
+    for len(pattern.times) > 0 && now > pattern.times[0] && now - pattern.times[0] > pattern.window {
+        pattern.recent = pattern.recent[:copy(pattern.recent, pattern.recent[1:])]
+        pattern.times = pattern.times[:copy(pattern.times, pattern.times[1:])]
+    }
+}
+
+// feed returns true if event completes the pattern
+func (pattern *SequencePattern) feed(event *ButtonEvent) bool {
+    if len(pattern.steps) == 0 || !pattern.relevant(event) {
+        return false
+    }
+    pattern.expire(event.Timestamp)
+    // === Push event (drop oldest if full) ===
+    if len(pattern.recent) == len(pattern.steps) {
+        pattern.recent = pattern.recent[:copy(pattern.recent, pattern.recent[1:])]
+        pattern.times = pattern.times[:copy(pattern.times, pattern.times[1:])]
+    }
+    pattern.recent = append(pattern.recent, SequenceStep{ButtonName: event.ButtonName, Type: event.Type})
+    pattern.times = append(pattern.times, event.Timestamp)
+    // === Compare with steps ===
+    if len(pattern.recent) < len(pattern.steps) {
+        return false
+    }
+    for i, step := range pattern.steps {
+        if pattern.recent[i] != step {
+            return false
+        }
+    }
+    pattern.Reset()
+    return true
+}
+
+// SequenceMatcher sits on top of the event stream of Buttons to emit EVT_SEQUENCE.
+// Timestamp of events is used for the time window, thus Buttons needs SetClock().
+// Partial matches also expire by Tick(), which Dispatch() calls for matchers given to AddSequenceMatcher()
+type SequenceMatcher struct {
+    patterns []*SequencePattern
+}
+
+func NewSequenceMatcher(pattern ...*SequencePattern) *SequenceMatcher {
+    return &SequenceMatcher {
+        patterns: append([]*SequencePattern{}, pattern...),
+    }
+}
+
+// Feed passes event got from Buttons to all patterns and returns EVT_SEQUENCE event if any pattern completes (nil otherwise).
+// If several patterns complete at once, the first registered one is reported
+func (matcher *SequenceMatcher) Feed(event *ButtonEvent) *ButtonEvent {
+    var matched *SequencePattern
+    for _, pattern := range matcher.patterns {
+        if pattern.feed(event) && matched == nil {
+            matched = pattern
+        }
+    }
+    if matched == nil {
+        return nil
+    }
+    return &ButtonEvent {
+        ButtonName: matched.name,
+        Type: EVT_SEQUENCE,
+        ScanCount: event.ScanCount,
+        Timestamp: event.Timestamp,
+        Sequence: event.Sequence,
+    }
+}
+
+// Tick expires partial matches older than the time window at now (us, by the clock of Buttons)
+func (matcher *SequenceMatcher) Tick(now uint64) {
+    for _, pattern := range matcher.patterns {
+        pattern.expire(now)
+    }
+}
+
+// Reset discards partial matches of all patterns
+func (matcher *SequenceMatcher) Reset() {
+    for _, pattern := range matcher.patterns {
+        pattern.Reset()
+    }
+}
+
+// AddSequenceMatcher makes Dispatch() feed events to matcher, call handlers for EVT_SEQUENCE it emits,
+// and expire its partial matches. SetClock() is needed before it
+func (buttons *Buttons) AddSequenceMatcher(matcher *SequenceMatcher) error {
+    if buttons.clock == nil {
+        return fmt.Errorf("pattern matcher needs clock of %s (SetClock)", buttons.name)
+    }
+    buttons.matchers = append(buttons.matchers, matcher)
+    return nil
+}
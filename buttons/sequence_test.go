@@ -0,0 +1,73 @@
+package buttons
+
+import (
+    "testing"
+    "time"
+)
+
+func newSequenceButtons(t *testing.T, now *uint64) (*Buttons, *testPin, *testPin, *int) {
+    up, down := &testPin{true}, &testPin{true}
+    buttons := New("test",
+        NewButton("up", up, DefaultButtonSingleConfig),
+        NewButton("down", down, DefaultButtonSingleConfig),
+    )
+    buttons.SetClock(func() uint64 { return *now })
+    matched := 0
+    buttons.On("updown", EVT_SEQUENCE, func(event *ButtonEvent) { matched++ })
+    err := buttons.AddSequenceMatcher(NewSequenceMatcher(
+        NewSequencePattern("updown", time.Second,
+            SequenceStep{ButtonName: "up", Type: EVT_SINGLE},
+            SequenceStep{ButtonName: "down", Type: EVT_SINGLE},
+        ),
+    ))
+    if err != nil {
+        t.Fatal(err)
+    }
+    return buttons, up, down, &matched
+}
+
+func clickAt(buttons *Buttons, pin *testPin, now *uint64, at uint64) {
+    *now = at
+    pin.level = false
+    ScanPeriodic(buttons)
+    *now = at + 50000
+    pin.level = true
+    ScanPeriodic(buttons)
+}
+
+func TestSequenceMatch(t *testing.T) {
+    var now uint64
+    buttons, up, down, matched := newSequenceButtons(t, &now)
+    clickAt(buttons, up, &now, 100000)
+    buttons.Dispatch()
+    clickAt(buttons, down, &now, 600000)
+    buttons.Dispatch()
+    if *matched != 1 {
+        t.Fatalf("matched %d times, want 1", *matched)
+    }
+}
+
+func TestSequenceExpireByDispatch(t *testing.T) {
+    var now uint64
+    buttons, up, down, matched := newSequenceButtons(t, &now)
+    clickAt(buttons, up, &now, 100000)
+    buttons.Dispatch()
+    // partial match expires without further events
+    now = 1200000
+    buttons.Dispatch()
+    // then down at a time which would be within the window of a stale up by Timestamp 0
+    buttons.SetClock(func() uint64 { return 0 })
+    down.level = false
+    ScanPeriodic(buttons)
+    buttons.Dispatch()
+    if *matched != 0 {
+        t.Fatalf("matched %d times, want 0", *matched)
+    }
+}
+
+func TestSequenceNeedsClock(t *testing.T) {
+    buttons := New("test")
+    if err := buttons.AddSequenceMatcher(NewSequenceMatcher()); err == nil {
+        t.Fatal("no error without clock")
+    }
+}
@@ -0,0 +1,71 @@
+package buttons
+
+import (
+    "fmt"
+)
+
+// OutputPin drives an output (e.g. machine.Pin configured as PinOutput)
+type OutputPin interface {
+    Set(high bool)
+}
+
+// ShiftRegister reads a chain of parallel-in/serial-out shift registers (74HC165) through
+// clock (CLK), latch (SH/LD) and data (QH of the chip nearest to MCU) pins.
+// It serves the input levels as PinBank for NewBankButton() (bit of Bit(chip, input)),
+// or as Sampler given to AddSampler() with virtual pins of Pin(bit) for NewButton()
+type ShiftRegister struct {
+    clock  OutputPin
+    latch  OutputPin
+    data   Pin
+    chips  int
+    levels uint32 // levels of the last Get()
+}
+
+const shiftRegisterMaxChips = 4
+
+func NewShiftRegister(clock, latch OutputPin, data Pin, chips int) (*ShiftRegister, error) {
+    if chips <= 0 || chips > shiftRegisterMaxChips {
+        return nil, fmt.Errorf("shift register chain of %d chips is out of range (1 to %d)", chips, shiftRegisterMaxChips)
+    }
+    clock.Set(false)
+    latch.Set(true)
+    return &ShiftRegister {
+        clock: clock,
+        latch: latch,
+        data: data,
+        chips: chips,
+        levels: ^uint32(0),
+    }, nil
+}
+
+// Bit returns bit of input (0: A to 7: H) of chip (0: nearest to MCU) to give NewBankButton()
+func (sr *ShiftRegister) Bit(chip, input int) uint8 {
+    return uint8(chip * 8 + input)
+}
+
+// Pin returns virtual pin of bit, which reads the levels of the last Sample() (or Get())
+func (sr *ShiftRegister) Pin(bit uint8) (Pin, error) {
+    return newSampledPin(&sr.levels, bit, sr.chips * 8)
+}
+
+func (sr *ShiftRegister) Sample() {
+    sr.Get()
+}
+
+// Get loads all inputs at once and shifts them out
+func (sr *ShiftRegister) Get() uint32 {
+    // === Load parallel inputs ===
+    sr.latch.Set(false)
+    sr.latch.Set(true)
+    // === Shift out from H of chip 0 to A of the last chip ===
+    var levels uint32
+    for i := 0; i < sr.chips * 8; i++ {
+        if sr.data.Get() {
+            levels |= 1 << sr.Bit(i / 8, 7 - i % 8)
+        }
+        sr.clock.Set(true)
+        sr.clock.Set(false)
+    }
+    sr.levels = levels
+    return levels
+}
@@ -0,0 +1,27 @@
+package sim
+
+import (
+    "time"
+)
+
+// Clock is a virtual clock advanced only by Advance() (or Runner)
+type Clock struct {
+    now time.Duration
+}
+
+func NewClock() *Clock {
+    return &Clock{}
+}
+
+func (clock *Clock) Now() time.Duration {
+    return clock.now
+}
+
+// Micros returns time in microseconds to give buttons.SetClock()
+func (clock *Clock) Micros() uint64 {
+    return uint64(clock.now / time.Microsecond)
+}
+
+func (clock *Clock) Advance(d time.Duration) {
+    clock.now += d
+}
@@ -0,0 +1,93 @@
+package sim
+
+import (
+    "math/rand"
+    "sort"
+    "time"
+)
+
+// Pin is a scripted pin whose level follows Press() / Release() / Toggle() / Bounce() at the time of Clock.
+// It implements buttons.Pin and is released at first
+type Pin struct {
+    clock      *Clock
+    activeHigh bool
+    steps      []pinStep
+    toggles    []time.Duration
+}
+
+type pinStep struct {
+    at     time.Duration
+    pushed bool
+}
+
+func NewPin(clock *Clock, activeHigh bool) *Pin {
+    return &Pin {
+        clock: clock,
+        activeHigh: activeHigh,
+    }
+}
+
+// Press pushes the button at time at
+func (pin *Pin) Press(at time.Duration) *Pin {
+    return pin.addStep(at, true)
+}
+
+// Release releases the button at time at
+func (pin *Pin) Release(at time.Duration) *Pin {
+    return pin.addStep(at, false)
+}
+
+// Click pushes the button at time at for duration d
+func (pin *Pin) Click(at, d time.Duration) *Pin {
+    return pin.Press(at).Release(at + d)
+}
+
+// Toggle inverts the scripted level at each time of at (an odd number of toggles keeps it inverted)
+func (pin *Pin) Toggle(at ...time.Duration) *Pin {
+    pin.toggles = append(pin.toggles, at...)
+    sort.Slice(pin.toggles, func(i, j int) bool { return pin.toggles[i] < pin.toggles[j] })
+    return pin
+}
+
+// Bounce adds contact chatter from time at for width (e.g. at Press / Release) by pairs of toggles at random times,
+// thus the level after width is the scripted one. The same seed gives the same chatter
+func (pin *Pin) Bounce(at, width time.Duration, seed int64) *Pin {
+    if width <= 0 {
+        return pin
+    }
+    rng := rand.New(rand.NewSource(seed))
+    toggles := make([]time.Duration, 2 * (1 + rng.Intn(4)))
+    for i := range toggles {
+        toggles[i] = at + time.Duration(rng.Int63n(int64(width)))
+    }
+    return pin.Toggle(toggles...)
+}
+
+func (pin *Pin) addStep(at time.Duration, pushed bool) *Pin {
+    pin.steps = append(pin.steps, pinStep{at, pushed})
+    sort.SliceStable(pin.steps, func(i, j int) bool { return pin.steps[i].at < pin.steps[j].at })
+    return pin
+}
+
+// Pushed returns scripted status at time now
+func (pin *Pin) Pushed() bool {
+    now := pin.clock.Now()
+    pushed := false
+    for _, step := range pin.steps {
+        if step.at > now {
+            break
+        }
+        pushed = step.pushed
+    }
+    for _, at := range pin.toggles {
+        if at > now {
+            break
+        }
+        pushed = !pushed
+    }
+    return pushed
+}
+
+func (pin *Pin) Get() bool {
+    return pin.Pushed() == pin.activeHigh
+}
@@ -0,0 +1,82 @@
+package sim
+
+import (
+    "time"
+
+    "github.com/elehobica/pico_tinygo_buttons/buttons"
+)
+
+// Runner steps ScanPeriodic of Buttons by the scan period of Clock and collects the events.
+// While Buttons is suspended by idle (SetIdleSuspend), it only advances the clock and calls Wake() of Buttons
+// at a level change of the pins given to WakeOn() as the edge interrupts would do
+type Runner struct {
+    Clock      *Clock
+    buttons    *buttons.Buttons
+    scanPeriod time.Duration
+    wakePins   []buttons.Pin
+    wakeLevels []bool
+}
+
+// NewRunner sets the clock and the scan period to btns
+func NewRunner(btns *buttons.Buttons, clock *Clock, scanPeriod time.Duration) *Runner {
+    btns.SetClock(clock.Micros)
+    btns.SetScanPeriod(scanPeriod)
+    return &Runner {
+        Clock: clock,
+        buttons: btns,
+        scanPeriod: scanPeriod,
+    }
+}
+
+// WakeOn sets pins whose level change wakes Buttons suspended by idle
+func (runner *Runner) WakeOn(pin ...buttons.Pin) {
+    runner.wakePins = append([]buttons.Pin{}, pin...)
+    runner.wakeLevels = make([]bool, len(pin))
+}
+
+// Step scans once (or checks the wake pins if suspended) and advances the clock by the scan period,
+// then returns the events of the scan
+func (runner *Runner) Step() []buttons.ButtonEvent {
+    runner.step()
+    return runner.drain(nil)
+}
+
+// Run scans for duration d and returns the events in order
+func (runner *Runner) Run(d time.Duration) []buttons.ButtonEvent {
+    var events []buttons.ButtonEvent
+    end := runner.Clock.Now() + d
+    for runner.Clock.Now() < end {
+        runner.step()
+        events = runner.drain(events)
+    }
+    return events
+}
+
+// RunUntil scans until the clock reaches time at and returns the events in order
+func (runner *Runner) RunUntil(at time.Duration) []buttons.ButtonEvent {
+    return runner.Run(at - runner.Clock.Now())
+}
+
+func (runner *Runner) step() {
+    if runner.buttons.IsSuspended() {
+        for i, pin := range runner.wakePins {
+            if level := pin.Get(); level != runner.wakeLevels[i] {
+                runner.wakeLevels[i] = level
+                runner.buttons.Wake()
+            }
+        }
+    } else {
+        buttons.ScanPeriodic(runner.buttons)
+        for i, pin := range runner.wakePins {
+            runner.wakeLevels[i] = pin.Get()
+        }
+    }
+    runner.Clock.Advance(runner.scanPeriod)
+}
+
+func (runner *Runner) drain(events []buttons.ButtonEvent) []buttons.ButtonEvent {
+    for event := runner.buttons.GetEvent(); event != nil; event = runner.buttons.GetEvent() {
+        events = append(events, *event)
+    }
+    return events
+}
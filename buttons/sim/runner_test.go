@@ -0,0 +1,171 @@
+package sim
+
+import (
+    "testing"
+    "time"
+
+    "github.com/elehobica/pico_tinygo_buttons/buttons"
+)
+
+const scanPeriod = 10 * time.Millisecond
+
+// simEvent is the part of ButtonEvent compared by tests
+type simEvent struct {
+    name  string
+    typ   buttons.ButtonEventType
+    scan  uint32
+    count uint8 // ClickCount of EVT_SINGLE / EVT_MULTI / EVT_CLICK_HOLD, RepeatCount of Repeat, Tier of hold tiers
+}
+
+func toSimEvents(events []buttons.ButtonEvent) []simEvent {
+    var simEvents []simEvent
+    for _, event := range events {
+        count := event.ClickCount
+        switch {
+        case event.RepeatCount > 0:
+            count = event.RepeatCount
+        case event.Type == buttons.EVT_LONG || event.Type == buttons.EVT_LONG_LONG || event.Type == buttons.EVT_HOLD:
+            count = event.Tier
+        }
+        simEvents = append(simEvents, simEvent{event.ButtonName, event.Type, event.ScanCount, count})
+    }
+    return simEvents
+}
+
+func checkSimEvents(t *testing.T, got, want []simEvent) {
+    t.Helper()
+    if len(got) != len(want) {
+        t.Fatalf("got %v, want %v", got, want)
+    }
+    for i := range got {
+        if got[i] != want[i] {
+            t.Fatalf("event %d: got %v, want %v", i, got[i], want[i])
+        }
+    }
+}
+
+func TestRunnerDetection(t *testing.T) {
+    const ms = time.Millisecond
+    filterConfig := buttons.NewButtonConfig(false, false, 3, 0, 0, 0, 0, 0)
+    for _, tc := range []struct {
+        name   string
+        config *buttons.ButtonConfig
+        script func(pin *Pin)
+        d      time.Duration
+        want   []simEvent
+    } {
+        {"single", buttons.DefaultButtonSingleConfig,
+            func(pin *Pin) { pin.Click(100*ms, 50*ms).Click(300*ms, 50*ms) }, time.Second,
+            []simEvent {
+                {"b", buttons.EVT_SINGLE, 10, 1},
+                {"b", buttons.EVT_SINGLE, 30, 1},
+            },
+        },
+        {"multi", buttons.DefaultButtonMultiConfig,
+            func(pin *Pin) { pin.Click(100*ms, 30*ms).Click(150*ms, 30*ms).Click(200*ms, 30*ms).Click(500*ms, 30*ms) }, time.Second,
+            []simEvent {
+                {"b", buttons.EVT_MULTI, 27, 3},
+                {"b", buttons.EVT_SINGLE, 57, 1},
+            },
+        },
+        {"long", buttons.DefaultButtonMultiConfig,
+            func(pin *Pin) { pin.Click(100*ms, 600*ms) }, time.Second,
+            []simEvent {
+                {"b", buttons.EVT_LONG, 24, 0},
+                {"b", buttons.EVT_LONG_LONG, 48, 1},
+                {"b", buttons.EVT_LONG_RELEASE, 70, 0},
+            },
+        },
+        {"tiers", buttons.DefaultButtonMultiConfig.WithHoldTiers(15, 39, 100),
+            func(pin *Pin) { pin.Click(100*ms, 1100*ms) }, 2*time.Second,
+            []simEvent {
+                {"b", buttons.EVT_LONG, 24, 0},
+                {"b", buttons.EVT_LONG_LONG, 48, 1},
+                {"b", buttons.EVT_HOLD, 109, 2},
+                {"b", buttons.EVT_LONG_RELEASE, 120, 0},
+            },
+        },
+        {"repeat", buttons.DefaultButtonSingleRepeatConfig,
+            func(pin *Pin) { pin.Click(100*ms, 200*ms) }, time.Second,
+            []simEvent {
+                {"b", buttons.EVT_SINGLE, 10, 1},
+                {"b", buttons.EVT_SINGLE, 19, 1},
+                {"b", buttons.EVT_SINGLE, 22, 2},
+                {"b", buttons.EVT_SINGLE, 25, 3},
+                {"b", buttons.EVT_SINGLE, 28, 4},
+            },
+        },
+        {"press release", buttons.DefaultButtonSingleConfig.WithPressRelease(true),
+            func(pin *Pin) { pin.Click(100*ms, 50*ms) }, time.Second,
+            []simEvent {
+                {"b", buttons.EVT_PRESS, 10, 0},
+                {"b", buttons.EVT_SINGLE, 10, 1},
+                {"b", buttons.EVT_RELEASE, 15, 0},
+            },
+        },
+        {"bounce without filter", buttons.DefaultButtonSingleConfig,
+            func(pin *Pin) { pin.Click(100*ms, 100*ms).Toggle(120*ms, 125*ms) }, time.Second,
+            []simEvent {
+                {"b", buttons.EVT_SINGLE, 10, 1},
+                {"b", buttons.EVT_SINGLE, 13, 1},
+            },
+        },
+        {"bounce filtered", filterConfig,
+            func(pin *Pin) { pin.Click(100*ms, 100*ms).Bounce(100*ms, 30*ms, 1).Bounce(200*ms, 30*ms, 2) }, time.Second,
+            []simEvent {
+                {"b", buttons.EVT_SINGLE, 15, 1},
+            },
+        },
+    } {
+        t.Run(tc.name, func(t *testing.T) {
+            clock := NewClock()
+            pin := NewPin(clock, false)
+            tc.script(pin)
+            runner := NewRunner(buttons.New("test", buttons.NewButton("b", pin, tc.config)), clock, scanPeriod)
+            checkSimEvents(t, toSimEvents(runner.Run(tc.d)), tc.want)
+        })
+    }
+}
+
+func TestPinBounceSeed(t *testing.T) {
+    clock := NewClock()
+    pin1 := NewPin(clock, false).Bounce(0, 50*time.Millisecond, 7)
+    pin2 := NewPin(clock, false).Bounce(0, 50*time.Millisecond, 7)
+    changes := 0
+    last := false
+    for clock.Now() < 100*time.Millisecond {
+        if pin1.Pushed() != pin2.Pushed() {
+            t.Fatalf("levels differ at %v by the same seed", clock.Now())
+        }
+        if pin1.Pushed() != last {
+            last = !last
+            changes++
+        }
+        clock.Advance(time.Millisecond / 4)
+    }
+    if changes == 0 || last {
+        t.Fatalf("%d changes, pushed %v after bounce", changes, last)
+    }
+}
+
+func TestRunnerIdleSuspend(t *testing.T) {
+    clock := NewClock()
+    pin := NewPin(clock, false).Click(500*time.Millisecond, 50*time.Millisecond)
+    btns := buttons.New("test", buttons.NewButton("b", pin, buttons.DefaultButtonSingleConfig))
+    runner := NewRunner(btns, clock, scanPeriod)
+    var suspendCnt, resumeCnt int
+    btns.SetIdleSuspend(10, func() { suspendCnt++ }, func() error { resumeCnt++; return nil })
+    runner.WakeOn(pin)
+    events := runner.Run(300 * time.Millisecond)
+    if len(events) != 0 || suspendCnt != 1 || !btns.IsSuspended() {
+        t.Fatalf("%d events, suspended %v (%d times)", len(events), btns.IsSuspended(), suspendCnt)
+    }
+    // no scan while suspended: the push at 500ms is detected by the scan of Wake() right after 10 scans to suspend
+    events = runner.RunUntil(time.Second)
+    checkSimEvents(t, toSimEvents(events), []simEvent {
+        {"b", buttons.EVT_SINGLE, 10, 1},
+    })
+    if resumeCnt != 1 || suspendCnt != 2 {
+        t.Fatalf("resumed %d times, suspended %d times", resumeCnt, suspendCnt)
+    }
+}
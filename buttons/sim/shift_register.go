@@ -0,0 +1,84 @@
+// Package sim drives buttons on host with a virtual clock, scripted pins and fakes of input hardware
+package sim
+
+import (
+    "github.com/elehobica/pico_tinygo_buttons/buttons"
+)
+
+// ShiftRegisterChain is a fake chain of 74HC165. Inputs[chip] holds the levels of inputs (bit 0: A to 7: H)
+// of each chip (0: nearest to MCU) and its Clock(), Latch() and Data() pins are given to buttons.NewShiftRegister()
+type ShiftRegisterChain struct {
+    Inputs []uint8
+    regs   []uint8
+    clock  bool
+    latch  bool
+}
+
+type shiftRegisterClock struct {
+    chain *ShiftRegisterChain
+}
+
+type shiftRegisterLatch struct {
+    chain *ShiftRegisterChain
+}
+
+type shiftRegisterData struct {
+    chain *ShiftRegisterChain
+}
+
+func NewShiftRegisterChain(chips int) *ShiftRegisterChain {
+    return &ShiftRegisterChain {
+        Inputs: make([]uint8, chips),
+        regs: make([]uint8, chips),
+        latch: true,
+    }
+}
+
+// Set sets the level of input (0: A to 7: H) of chip
+func (chain *ShiftRegisterChain) Set(chip, input int, high bool) {
+    if high {
+        chain.Inputs[chip] |= 1 << input
+    } else {
+        chain.Inputs[chip] &^= 1 << input
+    }
+}
+
+func (chain *ShiftRegisterChain) Clock() buttons.OutputPin {
+    return &shiftRegisterClock{chain}
+}
+
+func (chain *ShiftRegisterChain) Latch() buttons.OutputPin {
+    return &shiftRegisterLatch{chain}
+}
+
+func (chain *ShiftRegisterChain) Data() buttons.Pin {
+    return &shiftRegisterData{chain}
+}
+
+// rising edge of CLK shifts from A to H while SH/LD is high. SER of the last chip is tied low
+func (pin *shiftRegisterClock) Set(high bool) {
+    chain := pin.chain
+    if high && !chain.clock && chain.latch {
+        for i := range chain.regs {
+            chain.regs[i] <<= 1
+            if i + 1 < len(chain.regs) && chain.regs[i + 1] & 0x80 != 0 {
+                chain.regs[i] |= 1
+            }
+        }
+    }
+    chain.clock = high
+}
+
+// SH/LD low loads the inputs
+func (pin *shiftRegisterLatch) Set(high bool) {
+    chain := pin.chain
+    if !high {
+        copy(chain.regs, chain.Inputs)
+    }
+    chain.latch = high
+}
+
+func (pin *shiftRegisterData) Get() bool {
+    chain := pin.chain
+    return chain.regs[0] & 0x80 != 0
+}
@@ -0,0 +1,94 @@
+package sim
+
+import (
+    "testing"
+
+    "github.com/elehobica/pico_tinygo_buttons/buttons"
+)
+
+func newTestShiftRegister(t *testing.T, chips int) (*ShiftRegisterChain, *buttons.ShiftRegister) {
+    chain := NewShiftRegisterChain(chips)
+    sr, err := buttons.NewShiftRegister(chain.Clock(), chain.Latch(), chain.Data(), chips)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return chain, sr
+}
+
+func TestShiftRegisterPattern(t *testing.T) {
+    const pattern = 0x3ca5
+    chain, sr := newTestShiftRegister(t, 2)
+    chain.Inputs[0] = pattern & 0xff
+    chain.Inputs[1] = pattern >> 8
+    if levels := sr.Get(); levels != pattern {
+        t.Fatalf("snapshot %04x, want %04x", levels, pattern)
+    }
+    for chip := 0; chip < 2; chip++ {
+        for input := 0; input < 8; input++ {
+            bit := sr.Bit(chip, input)
+            pin, err := sr.Pin(bit)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if want := pattern & (1 << bit) != 0; pin.Get() != want {
+                t.Fatalf("chip %d input %d: pin %v, want %v", chip, input, pin.Get(), want)
+            }
+        }
+    }
+    // inputs changed after the snapshot are not seen until the next one
+    chain.Set(1, 7, true)
+    pin, _ := sr.Pin(sr.Bit(1, 7))
+    if pin.Get() {
+        t.Fatal("pin changed without sample")
+    }
+    sr.Sample()
+    if !pin.Get() {
+        t.Fatal("pin not changed by sample")
+    }
+}
+
+func TestShiftRegisterChainLength(t *testing.T) {
+    for chips := 1; chips <= 4; chips++ {
+        chain, sr := newTestShiftRegister(t, chips)
+        var want uint32
+        for chip := 0; chip < chips; chip++ {
+            chain.Inputs[chip] = uint8(0x11 * (chip + 1))
+            want |= uint32(chain.Inputs[chip]) << (8 * chip)
+        }
+        if levels := sr.Get(); levels != want {
+            t.Fatalf("%d chips: snapshot %08x, want %08x", chips, levels, want)
+        }
+        if _, err := sr.Pin(uint8(chips * 8)); err == nil {
+            t.Fatalf("%d chips: no error for pin out of chain", chips)
+        }
+    }
+    if _, err := buttons.NewShiftRegister(nil, nil, nil, 5); err == nil {
+        t.Fatal("no error for 5 chips")
+    }
+}
+
+func TestShiftRegisterButtons(t *testing.T) {
+    chain, sr := newTestShiftRegister(t, 2)
+    chain.Inputs[0], chain.Inputs[1] = 0xff, 0xff
+    bankButton, err := buttons.NewBankButton("bank", sr.Bit(1, 2), buttons.DefaultButtonSingleConfig)
+    if err != nil {
+        t.Fatal(err)
+    }
+    pin, err := sr.Pin(sr.Bit(0, 5))
+    if err != nil {
+        t.Fatal(err)
+    }
+    btns := buttons.New("test", bankButton, buttons.NewButton("pin", pin, buttons.DefaultButtonSingleConfig))
+    btns.SetPinBank(sr)
+    buttons.ScanPeriodic(btns)
+    chain.Set(1, 2, false)
+    chain.Set(0, 5, false)
+    buttons.ScanPeriodic(btns)
+    var names []string
+    for event := btns.GetEvent(); event != nil; event = btns.GetEvent() {
+        names = append(names, event.ButtonName)
+    }
+    if len(names) != 2 || names[0] != "bank" || names[1] != "pin" {
+        t.Fatalf("events of %v", names)
+    }
+}
@@ -3,7 +3,7 @@ package main
 import (
     "fmt"
     "machine"
-    //"time"
+    "time"
 
     "github.com/elehobica/pico_tinygo_buttons/mymachine"
     "github.com/elehobica/pico_tinygo_buttons/buttons"
@@ -31,6 +31,8 @@ func (pin Pin) Toggle() {
     pin.Set(!pin.Get())
 }
 
+const scanPeriod = 50 * time.Millisecond
+
 var t uint64
 var scanCnt uint32
 
@@ -51,43 +53,146 @@ func main() {
     upBtnPin.Configure(machine.PinConfig{Mode: machine.PinInputPullup})
     downBtnPin.Configure(machine.PinConfig{Mode: machine.PinInputPullup})
 
-    btns := buttons.New("5WayTactile+2",
-        []*buttons.Button {
-            buttons.NewButton("reset",  &resetBtnPin,  buttons.DefaultButtonSingleConfig),
-            buttons.NewButton("set",    &setBtnPin,    buttons.DefaultButtonSingleConfig),
-            buttons.NewButton("center", &centerBtnPin, buttons.DefaultButtonMultiConfig),
-            buttons.NewButton("left",   &leftBtnPin,   buttons.DefaultButtonSingleRepeatConfig),
-            buttons.NewButton("right",  &rightBtnPin,  buttons.DefaultButtonSingleRepeatConfig),
-            buttons.NewButton("up",     &upBtnPin,     buttons.DefaultButtonSingleRepeatConfig),
-            buttons.NewButton("down",   &downBtnPin,   buttons.DefaultButtonSingleRepeatConfig),
-        }...
-    );
-
-    err := mymachine.SetRepeatedTimerAlarm("alarm1", mymachine.ALARM1, 50*1000, buttonScan, btns)
+    // Repeat every 3 scans, then every 2 scans after 1 sec, then every scan after 2 sec
+    accelRepeatConfig := buttons.DefaultButtonSingleRepeatConfig.WithRepeatProfile(
+        buttons.RepeatStage{AfterCnt: 20, Skip: 1},
+        buttons.RepeatStage{AfterCnt: 40, Skip: 0},
+    )
+
+    // Long at 0.75 sec, LongLong at 1.95 sec and 3rd hold tier at 5 sec
+    centerConfig := buttons.DefaultButtonMultiConfig.WithClickHold(true).WithHoldTiers(15, 39, 100)
+
+    // all buttons are scanned from a single snapshot of GPIO inputs
+    btns := buttons.New("5WayTactile+2")
+    for _, def := range []struct {
+        name   string
+        pin    machine.Pin
+        config *buttons.ButtonConfig
+    } {
+        {"reset",  resetBtnPin,  buttons.DefaultButtonSingleConfig},
+        {"set",    setBtnPin,    buttons.DefaultButtonSingleConfig},
+        {"center", centerBtnPin, centerConfig},
+        {"left",   leftBtnPin,   buttons.DefaultButtonSingleRepeatConfig},
+        {"right",  rightBtnPin,  buttons.DefaultButtonSingleRepeatConfig},
+        {"up",     upBtnPin,     accelRepeatConfig},
+        {"down",   downBtnPin,   accelRepeatConfig},
+    } {
+        button, err := buttons.NewBankButton(def.name, uint8(def.pin), def.config)
+        if err == nil {
+            err = btns.AddButton(button)
+        }
+        if err != nil {
+            println(err)
+            return
+        }
+    }
+    btns.SetPinBank(mymachine.GpioBank{})
+    btns.SetClock(mymachine.TimeElapsed)
+    btns.SetScanPeriod(scanPeriod)
+    err := btns.AddChord(buttons.NewChord("set+reset", 40, buttons.CHORD_RELEASE_ALL, "set", "reset"))
     if err != nil {
         println(err)
         return
     }
 
-    for loop := 0; true; loop++ {
-        for event := btns.GetEvent(); event != nil; event = btns.GetEvent() {
-            switch event.Type {
-            case buttons.EVT_SINGLE:
-                if event.RepeatCount > 0 {
-                    fmt.Printf("%s: 1 (Repeated %d)\r\n", event.ButtonName, event.RepeatCount)
-                } else {
-                    fmt.Printf("%s: 1\r\n", event.ButtonName)
-                }
-            case buttons.EVT_MULTI:
-                fmt.Printf("%s: %d\r\n", event.ButtonName, event.ClickCount)
-                if event.ButtonName == "center" && event.ClickCount == 3 {
-                    fmt.Printf("time %dus (scan: %d)\r\n", t, scanCnt)
-                }
-            case buttons.EVT_LONG:
-                fmt.Printf("%s: Long\r\n", event.ButtonName)
-            case buttons.EVT_LONG_LONG:
-                fmt.Printf("%s: LongLong\r\n", event.ButtonName)
+    err = btns.AddSequenceMatcher(buttons.NewSequenceMatcher(
+        buttons.NewSequencePattern("service", 3*time.Second,
+            buttons.SequenceStep{ButtonName: "up",     Type: buttons.EVT_SINGLE},
+            buttons.SequenceStep{ButtonName: "up",     Type: buttons.EVT_SINGLE},
+            buttons.SequenceStep{ButtonName: "down",   Type: buttons.EVT_SINGLE},
+            buttons.SequenceStep{ButtonName: "down",   Type: buttons.EVT_SINGLE},
+            buttons.SequenceStep{ButtonName: "center", Type: buttons.EVT_SINGLE},
+        ),
+    ))
+    if err != nil {
+        println(err)
+        return
+    }
+
+    // stop scan after 10 sec idle and wake up by falling edge of any button
+    wakePins := []mymachine.Pin {
+        mymachine.Pin(resetBtnPin), mymachine.Pin(setBtnPin), mymachine.Pin(centerBtnPin),
+        mymachine.Pin(leftBtnPin), mymachine.Pin(rightBtnPin), mymachine.Pin(upBtnPin), mymachine.Pin(downBtnPin),
+    }
+    // callbacks below run in interrupt, thus everything they use is prepared here not to allocate
+    wake := func(mymachine.Pin) {
+        btns.Wake()
+    }
+    btns.SetIdleSuspend(uint32(10*time.Second/scanPeriod),
+        func() {
+            mymachine.CancelTimerAlarm(mymachine.ALARM1)
+            for _, pin := range wakePins {
+                pin.SetInterrupt(mymachine.PinFalling, wake)
+            }
+        },
+        func() error {
+            // keep edge interrupts enabled to retry if the scan doesn't restart
+            if err := mymachine.RestartTimerAlarm(mymachine.ALARM1); err != nil {
+                return err
             }
+            for _, pin := range wakePins {
+                pin.SetInterrupt(mymachine.PinFalling, nil)
+            }
+            return nil
+        },
+    )
+
+    err = mymachine.SetRepeatedTimerAlarm("alarm1", mymachine.ALARM1, uint32(scanPeriod/time.Microsecond), buttonScan, btns)
+    if err != nil {
+        println(err)
+        return
+    }
+
+    btns.On("", buttons.EVT_SINGLE, func(event *buttons.ButtonEvent) {
+        if event.RepeatCount > 0 {
+            fmt.Printf("%s: 1 (Repeated %d)\r\n", event.ButtonName, event.RepeatCount)
+        } else {
+            fmt.Printf("%s: 1\r\n", event.ButtonName)
+        }
+    })
+    btns.On("", buttons.EVT_MULTI, func(event *buttons.ButtonEvent) {
+        fmt.Printf("%s: %d\r\n", event.ButtonName, event.ClickCount)
+    })
+    btns.On("center", buttons.EVT_MULTI, func(event *buttons.ButtonEvent) {
+        if event.ClickCount == 3 {
+            fmt.Printf("time %dus (scan: %d)\r\n", t, scanCnt)
+        }
+    })
+    btns.On("", buttons.EVT_LONG, func(event *buttons.ButtonEvent) {
+        fmt.Printf("%s: Long\r\n", event.ButtonName)
+    })
+    btns.On("", buttons.EVT_LONG_LONG, func(event *buttons.ButtonEvent) {
+        fmt.Printf("%s: LongLong\r\n", event.ButtonName)
+    })
+    btns.On("", buttons.EVT_HOLD, func(event *buttons.ButtonEvent) {
+        fmt.Printf("%s: Hold Tier %d\r\n", event.ButtonName, event.Tier)
+    })
+    btns.On("", buttons.EVT_LONG_RELEASE, func(event *buttons.ButtonEvent) {
+        held := time.Duration(event.HoldCount) * btns.GetScanPeriod()
+        fmt.Printf("%s: Long Release (%dms)\r\n", event.ButtonName, held/time.Millisecond)
+    })
+    btns.On("", buttons.EVT_CLICK_HOLD, func(event *buttons.ButtonEvent) {
+        fmt.Printf("%s: %d + Hold\r\n", event.ButtonName, event.ClickCount)
+    })
+    btns.On("", buttons.EVT_CHORD, func(event *buttons.ButtonEvent) {
+        fmt.Printf("%s: Chord\r\n", event.ButtonName)
+    })
+    btns.On("", buttons.EVT_CHORD_RELEASE, func(event *buttons.ButtonEvent) {
+        fmt.Printf("%s: Chord Release\r\n", event.ButtonName)
+    })
+    btns.On("", buttons.EVT_OVERFLOW, func(event *buttons.ButtonEvent) {
+        fmt.Printf("%s: Overflow (total %d)\r\n", event.ButtonName, btns.GetOverflowCount())
+    })
+    btns.On("", buttons.EVT_SEQUENCE, func(event *buttons.ButtonEvent) {
+        fmt.Printf("%s: Sequence\r\n", event.ButtonName)
+    })
+
+    var resumeErrCnt uint32
+    for loop := 0; true; loop++ {
+        btns.Dispatch()
+        if cnt := btns.GetResumeErrorCount(); cnt != resumeErrCnt {
+            resumeErrCnt = cnt
+            fmt.Printf("scan restart failed (total %d)\r\n", cnt)
         }
         led.Toggle()
         //time.Sleep(100 * time.Millisecond)
@@ -0,0 +1,129 @@
+//go:build rp2040
+// +build rp2040
+
+package mymachine
+
+import (
+    "device/rp"
+    "errors"
+    "runtime/interrupt"
+    "runtime/volatile"
+    "unsafe"
+)
+
+// GpioBank reads all GPIO inputs at once by SIO GPIO_IN register, where bit n is the level of GPn
+// (implements buttons.PinBank)
+type GpioBank struct{}
+
+func (bank GpioBank) Get() uint32 {
+    return rp.SIO.GPIO_IN.Get()
+}
+
+// Pin is GPIO number to set edge/level interrupt (same number as machine.Pin)
+// Note that it conflicts with machine.Pin.SetInterrupt() which also handles IRQ_IO_IRQ_BANK0
+type Pin uint8
+
+type PinChange uint8
+const (
+    PinLevelLow PinChange = 1 << iota
+    PinLevelHigh
+    PinFalling
+    PinRising
+    PinToggle = PinFalling | PinRising
+)
+
+type ioType struct {
+    status volatile.Register32
+    ctrl   volatile.Register32
+}
+
+type irqCtrl struct {
+    intE [4]volatile.Register32
+    intF [4]volatile.Register32
+    intS [4]volatile.Register32
+}
+
+type ioBank0Type struct {
+    io                 [_NUMBANK0_GPIOS]ioType
+    intR               [4]volatile.Register32
+    proc0IRQctrl       irqCtrl
+    proc1IRQctrl       irqCtrl
+    dormantWakeIRQctrl irqCtrl
+}
+
+var ioBank0 = (*ioBank0Type)(unsafe.Pointer(rp.IO_BANK0))
+
+var (
+    pinCallbacks [2][_NUMBANK0_GPIOS]func(Pin)
+    setInt       [2]bool
+)
+
+var (
+    errInvalidPin      = errors.New("invalid GPIO pin")
+    errCallbackAlready = errors.New("interrupt callback already set")
+)
+
+// ioIntBit returns bit of change of the pin in INTR, INTE, INTF and INTS registers (4 bits per pin, 8 pins per register)
+func (p Pin) ioIntBit(change PinChange) uint32 {
+    return uint32(change) << (4 * (p % 8))
+}
+
+func getIntChange(p Pin, status uint32) PinChange {
+    return PinChange(status >> (4 * (p % 8))) & 0xf
+}
+
+// SetInterrupt calls callback (in interrupt) on change of the pin for the executing core, nil callback disables it.
+// Set nil callback once before setting another callback. It doesn't allocate, thus it is safe to call in interrupt
+func (p Pin) SetInterrupt(change PinChange, callback func(Pin)) error {
+    if p >= _NUMBANK0_GPIOS {
+        return errInvalidPin
+    }
+    core := CurrentCore()
+    if callback == nil {
+        p.setInterrupt(change, false)
+        pinCallbacks[core][p] = nil
+        return nil
+    }
+    if pinCallbacks[core][p] != nil {
+        return errCallbackAlready
+    }
+    p.setInterrupt(change, true)
+    pinCallbacks[core][p] = callback
+    if !setInt[core] {
+        setInt[core] = true
+        interrupt.New(rp.IRQ_IO_IRQ_BANK0, gpioHandleInterrupt).Enable()
+        irqSet(rp.IRQ_IO_IRQ_BANK0, true)
+    }
+    return nil
+}
+
+func gpioHandleInterrupt(intr interrupt.Interrupt) {
+    core := CurrentCore()
+    base := &ioBank0.proc0IRQctrl
+    if core == 1 {
+        base = &ioBank0.proc1IRQctrl
+    }
+    for gpio := Pin(0); gpio < _NUMBANK0_GPIOS; gpio++ {
+        change := getIntChange(gpio, base.intS[gpio >> 3].Get())
+        if change == 0 {
+            continue
+        }
+        gpio.acknowledgeInterrupt(change)
+        if callback := pinCallbacks[core][gpio]; callback != nil {
+            callback(gpio)
+        }
+    }
+}
+
+// GpioMatrixRow drives GPIO of the number low or releases it to high impedance by output enable of SIO
+// (implements buttons.MatrixRow). Configure the pin as machine.PinInput beforehand to select SIO function
+type GpioMatrixRow uint8
+
+func (row GpioMatrixRow) Drive() {
+    rp.SIO.GPIO_OUT_CLR.Set(1 << row)
+    rp.SIO.GPIO_OE_SET.Set(1 << row)
+}
+
+func (row GpioMatrixRow) Release() {
+    rp.SIO.GPIO_OE_CLR.Set(1 << row)
+}
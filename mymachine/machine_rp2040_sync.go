@@ -19,7 +19,6 @@ const (
 	_NUMBANK0_GPIOS       = 30
 )
 
-/*
 // Clears interrupt flag on a pin
 func (p Pin) acknowledgeInterrupt(change PinChange) {
 	ioBank0.intR[p>>3].Set(p.ioIntBit(change))
@@ -51,7 +50,11 @@ func (p Pin) ctrlSetInterrupt(change PinChange, enabled bool, base *irqCtrl) {
 		enReg.ClearBits(p.ioIntBit(change))
 	}
 }
-*/
+
+// CurrentCore returns the core number the call was made from.
+func CurrentCore() int {
+	return int(rp.SIO.CPUID.Get())
+}
 
 // Enable or disable a specific interrupt on the executing core.
 // num is the interrupt number which must be in [0,31].
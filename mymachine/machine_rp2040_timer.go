@@ -7,6 +7,7 @@ import (
     "runtime/interrupt"
     "runtime/volatile"
     "unsafe"
+    "errors"
     "fmt"
 )
 
@@ -48,6 +49,7 @@ type Alarm struct {
     target    uint64
     callback  callbackType
     opts      []interface{}
+    stopped   bool // stopped by CancelTimerAlarm() keeping the setting
 }
 
 const minInterval uint32 = 2 // us
@@ -111,6 +113,7 @@ func setTimerAlarm(name string, alarmId AlarmId, us uint32, repeat bool, callbac
     almAry[alarmId].target = timer.timeElapsed() + uint64(almAry[alarmId].interval)
     almAry[alarmId].callback = callback
     almAry[alarmId].opts = opts
+    almAry[alarmId].stopped = false
 
     if almAry[alarmId].callback != nil {
         now := timer.timeElapsed()
@@ -136,6 +139,44 @@ func SetOneshotTimerAlarm(name string, alarmId AlarmId, us uint32, callback call
     return setTimerAlarm(name, alarmId, us, false, callback, opts...)
 }
 
+var (
+    errAlarmIdOver = errors.New("AlarmId over")
+    errAlarmNotSet = errors.New("alarm is not set")
+)
+
+// CancelTimerAlarm stops the alarm keeping its setting for RestartTimerAlarm(), it is safe to call from its own callback
+func CancelTimerAlarm(alarmId AlarmId) error {
+    if alarmId >= __NUM_ALARMS__ {
+        return errAlarmIdOver
+    }
+    alarm := almAry[alarmId]
+    if alarm == nil || alarm.callback == nil || alarm.stopped {
+        return nil
+    }
+    alarm.stopped = true
+    return setTimerIrq(alarmId, false)
+}
+
+// RestartTimerAlarm restarts the alarm stopped by CancelTimerAlarm() with the same setting.
+// It doesn't allocate, thus it is safe to call in interrupt
+func RestartTimerAlarm(alarmId AlarmId) error {
+    if alarmId >= __NUM_ALARMS__ {
+        return errAlarmIdOver
+    }
+    alarm := almAry[alarmId]
+    if alarm == nil || alarm.callback == nil {
+        return errAlarmNotSet
+    }
+    if !alarm.stopped {
+        return nil
+    }
+    alarm.stopped = false
+    alarm.target = timer.timeElapsed() + uint64(alarm.interval)
+    timer.intR.Set(1 << alarmId) // clear the interrupt raised while stopped
+    timer.alarm[alarmId].Set(uint32(alarm.target))
+    return setTimerIrq(alarmId, true)
+}
+
 func timerHandleInterrupt1(intr interrupt.Interrupt) {
     timerHandleInterrupt(ALARM1, intr)
 }
@@ -164,6 +205,10 @@ func timerHandleInterrupt(alarmId AlarmId, intr interrupt.Interrupt) {
     }
     // Do callback
     almAry[alarmId].callback(almAry[alarmId].name, alarmId, almAry[alarmId].opts...)
+    // Stopped by callback
+    if almAry[alarmId].stopped {
+        return
+    }
     // Prepare for repeated alarm
     if almAry[alarmId].repeat {
         almAry[alarmId].target += uint64(almAry[alarmId].interval)